@@ -0,0 +1,6 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1
+
+// Hub marks v1 as the storage version that the other versions of IamPolicy are converted to and from.
+func (*IamPolicy) Hub() {}
@@ -9,6 +9,10 @@
 package v1
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -24,6 +28,9 @@ type RemediationAction string
 const (
 	// Inform is an remediationAction to only inform
 	Inform RemediationAction = "Inform"
+
+	// Enforce is an remediationAction to remove excess subjects from cluster role bindings
+	Enforce RemediationAction = "Enforce"
 )
 
 // ComplianceState shows the state of enforcement
@@ -40,44 +47,313 @@ const (
 	UnknownCompliancy ComplianceState = "UnknownCompliancy"
 )
 
+const (
+	// ConditionCompliant is the condition type set to True when the policy is compliant, False when it is
+	// non-compliant, and Unknown when the compliance is not determined
+	ConditionCompliant = "Compliant"
+
+	// ConditionEvaluationSucceeded is the condition type set to False when the last evaluation of the policy
+	// encountered errors
+	ConditionEvaluationSucceeded = "EvaluationSucceeded"
+
+	// ConditionReady is the condition type set to True when the policy was successfully evaluated and its
+	// compliance is determined
+	ConditionReady = "Ready"
+)
+
 // Target defines the list of namespaces to include/exclude
 type Target struct {
 	Include []NonEmptyString `json:"include,omitempty"`
 	Exclude []NonEmptyString `json:"exclude,omitempty"`
 }
 
+// ClusterRoleLimit is a cluster role and the maximum number of users that can be bound to it
+type ClusterRoleLimit struct {
+	// Name of the cluster role referenced by the role bindings
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Maximum number of users bound to the cluster role still valid before it is considered non-compliant
+	// +kubebuilder:validation:Minimum=1
+	MaxUsers int `json:"maxUsers"`
+}
+
+// PrivilegedRule is a set of permissions that make a cluster role privileged when it grants all of them
+type PrivilegedRule struct {
+	// The API groups of the resources, defaults to the core API group. The value `*` is only matched by a
+	// cluster role granting all API groups.
+	APIGroups []string `json:"apiGroups,omitempty"`
+	// The resources, for example `*` or `secrets`
+	// +kubebuilder:validation:MinItems=1
+	Resources []string `json:"resources"`
+	// The verbs, for example `*` or `get`
+	// +kubebuilder:validation:MinItems=1
+	Verbs []string `json:"verbs"`
+}
+
+// IgnoredSubject selects the subjects that are not counted, by their kind and a regex of their name
+type IgnoredSubject struct {
+	// Kind of the subject. Users resolved from groups are also matched by the User patterns.
+	// +kubebuilder:validation:Enum=User;Group;ServiceAccount
+	Kind string `json:"kind"`
+	// Regex matched against the name of the subject, or against <namespace>:<name> for a ServiceAccount
+	Name NonEmptyString `json:"name"`
+}
+
+// UnresolvableGroupsMode is how the groups whose users can't be resolved are evaluated
+// +kubebuilder:validation:Enum=CountAsOne;CountAsUnlimited;Unknown;Ignore
+type UnresolvableGroupsMode string
+
+const (
+	// CountAsOne counts an unresolvable group as a single user
+	CountAsOne UnresolvableGroupsMode = "CountAsOne"
+	// CountAsUnlimited counts an unresolvable group as an unlimited number of users, so the policy is
+	// non-compliant
+	CountAsUnlimited UnresolvableGroupsMode = "CountAsUnlimited"
+	// Unknown makes the compliance of the policy unknown unless it is already non-compliant
+	Unknown UnresolvableGroupsMode = "Unknown"
+	// Ignore counts an unresolvable group as no users
+	Ignore UnresolvableGroupsMode = "Ignore"
+)
+
+// EvaluationInterval configures the minimum time between evaluations of the policy, based on its
+// compliance state
+type EvaluationInterval struct {
+	// The minimum time between evaluations when the policy is compliant, as a duration such as 10s or 2h30m,
+	// or never to stop evaluating the policy once it is compliant. Defaults to the --update-frequency flag of
+	// the controller.
+	// +kubebuilder:validation:Pattern=`^(?:(?:[0-9]+(?:\.[0-9]+)?(?:h|m|s|ms|us|ns))+|never)$`
+	Compliant string `json:"compliant,omitempty"`
+	// The minimum time between evaluations when the policy is not compliant, as a duration such as 10s or
+	// 2h30m, or never to stop evaluating the policy once it is not compliant. Defaults to the --update-frequency
+	// flag of the controller.
+	// +kubebuilder:validation:Pattern=`^(?:(?:[0-9]+(?:\.[0-9]+)?(?:h|m|s|ms|us|ns))+|never)$`
+	NonCompliant string `json:"noncompliant,omitempty"`
+}
+
+// ErrIsNever is returned when the evaluation interval is set to never
+var ErrIsNever = errors.New("the interval is set to never")
+
+// GetCompliantInterval returns the evaluation interval when the policy is compliant, or zero when it is not
+// set. ErrIsNever is returned when it is set to never.
+func (e EvaluationInterval) GetCompliantInterval() (time.Duration, error) {
+	return parseInterval(e.Compliant)
+}
+
+// GetNonCompliantInterval returns the evaluation interval when the policy is not compliant, or zero when it is
+// not set. ErrIsNever is returned when it is set to never.
+func (e EvaluationInterval) GetNonCompliantInterval() (time.Duration, error) {
+	return parseInterval(e.NonCompliant)
+}
+
+func parseInterval(interval string) (time.Duration, error) {
+	if interval == "" {
+		return 0, nil
+	}
+
+	if interval == "never" {
+		return 0, ErrIsNever
+	}
+
+	parsed, err := time.ParseDuration(interval)
+	if err != nil {
+		return 0, fmt.Errorf("the evaluation interval '%s' is not a valid duration: %w", interval, err)
+	}
+
+	return parsed, nil
+}
+
 // IamPolicySpec defines the desired state of IamPolicy
 type IamPolicySpec struct {
 	// A list of regex values signifying which cluster role binding names to ignore.
 	// By default, all cluster role bindings that have a name which starts with system:
 	// will be ignored. It is recommended to set this to a stricter value.
 	IgnoreClusterRoleBindings []NonEmptyString `json:"ignoreClusterRoleBindings,omitempty"`
-	// Only Inform is currently supported. Setting this to Enforce will have the same effect as Inform.
+	// The subjects that are not counted or evaluated, by kind and name regex. Unlike
+	// ignoreClusterRoleBindings, the other subjects of the same bindings are still evaluated.
+	IgnoreSubjects []IgnoredSubject `json:"ignoreSubjects,omitempty"`
+	// Inform only reports violations. Enforce also removes subjects from the cluster role bindings that are
+	// not ignored, until the number of users is within maxClusterRoleBindingUsers. Subjects of the most
+	// recently created cluster role bindings are removed first, starting from the last subject of each binding.
 	RemediationAction RemediationAction `json:"remediationAction,omitempty"`
-	// Selecting a list of namespaces where the policy applies. This field is obsolete and does not
-	// do anything.
-	NamespaceSelector Target            `json:"namespaceSelector,omitempty"`
-	LabelSelector     map[string]string `json:"labelSelector,omitempty"`
+	// Selecting a list of namespaces where the role bindings referencing the cluster role are also
+	// evaluated. Each namespace with users bound to the cluster role is compared to
+	// maxClusterRoleBindingUsers separately. The include and exclude values support wildcards such as
+	// `kube-*`. Role bindings are not modified when the policy is enforced.
+	NamespaceSelector Target `json:"namespaceSelector,omitempty"`
+	// Only evaluate the cluster role bindings with these labels. This is combined with
+	// labelSelectorExpressions, and all cluster role bindings are evaluated when neither is set.
+	LabelSelector map[string]string `json:"labelSelector,omitempty"`
+	// Only evaluate the cluster role bindings with labels matching all of these set-based requirements, such as
+	// the In, NotIn, Exists, and DoesNotExist operators
+	LabelSelectorExpressions []metav1.LabelSelectorRequirement `json:"labelSelectorExpressions,omitempty"`
 	// Maximum number of cluster role binding users still valid before it is considered non-compliant
 	// +kubebuilder:validation:Minimum=1
 	MaxClusterRoleBindingUsers int `json:"maxClusterRoleBindingUsers,omitempty"`
-	// Name of the cluster role referenced by the cluster role bindings, defaults to "cluster-admin" if none specified
+	// Name of the cluster role referenced by the cluster role bindings, defaults to "cluster-admin" if none specified.
+	// The bindings to the cluster roles aggregated into it, and to the cluster roles it is aggregated into, are
+	// also evaluated.
 	// +kubebuilder:validation:MinLength=1
 	ClusterRole string `json:"clusterRole,omitempty"`
+	// A list of cluster roles to evaluate, each with its own limit. When set, clusterRole and
+	// maxClusterRoleBindingUsers are ignored, and each cluster role is reported separately in the compliancy
+	// details with keys in the <scope>/<cluster role> format, where the scope is cluster-wide or a namespace.
+	ClusterRoles []ClusterRoleLimit `json:"clusterRoles,omitempty"`
+	// Evaluate the cluster roles by the permissions they grant rather than by name. Every cluster role granting
+	// all the permissions of at least one rule is privileged, and the users bound to any privileged cluster role
+	// are counted together against maxClusterRoleBindingUsers. When set, clusterRole and clusterRoles are
+	// ignored and the violations are reported for the "privileged" role.
+	PrivilegedRules []PrivilegedRule `json:"privilegedRules,omitempty"`
+	// Count the ServiceAccount subjects bound to the cluster role as users. A ServiceAccount is identified as
+	// system:serviceaccount:<namespace>:<name>, so a User subject with that name is counted as the same user.
+	IncludeServiceAccounts bool `json:"includeServiceAccounts,omitempty"`
+	// The users allowed to be bound to the cluster role. When any of the allowed lists are set, the policy is
+	// non-compliant if a user that is not approved by them is bound to the cluster role.
+	AllowedUsers []NonEmptyString `json:"allowedUsers,omitempty"`
+	// The groups allowed to be bound to the cluster role. All members of these groups are approved.
+	AllowedGroups []NonEmptyString `json:"allowedGroups,omitempty"`
+	// The service accounts allowed to be bound to the cluster role, in the <namespace>:<name> format. This only
+	// has an effect when includeServiceAccounts is true.
+	AllowedServiceAccounts []NonEmptyString `json:"allowedServiceAccounts,omitempty"`
+	// A list of regex values signifying which subjects must never be bound to the cluster role. The names of the
+	// subjects are matched, as well as the users that group subjects resolve to. Service accounts are matched as
+	// system:serviceaccount:<namespace>:<name> when includeServiceAccounts is true.
+	ForbiddenSubjects []NonEmptyString `json:"forbiddenSubjects,omitempty"`
+	// The maximum number of compliance state transitions kept in the status, defaults to 10. Set it to 0 to not
+	// keep a compliance history.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	ComplianceHistoryLimit *int `json:"complianceHistoryLimit,omitempty"`
+	// The minimum time between evaluations of the policy, which can differ when it is compliant and when it is
+	// not. A change to the spec of the policy is always evaluated.
+	EvaluationInterval EvaluationInterval `json:"evaluationInterval,omitempty"`
+	// How the groups whose users can't be resolved are evaluated, such as groups that the group resolver
+	// doesn't know. Either CountAsOne, CountAsUnlimited, Unknown, or Ignore, which is the default. The
+	// unresolved groups are listed in the evaluations regardless.
+	UnresolvableGroups UnresolvableGroupsMode `json:"unresolvableGroups,omitempty"`
+	// Honor the policy.open-cluster-management.io/approved-until annotation of the cluster role bindings. The
+	// users only granted the role by approved bindings are not counted or removed until the approval expires.
+	AllowApprovals bool `json:"allowApprovals,omitempty"`
+	// The longest time an approval can extend into the future, as a duration such as 72h, which defaults to
+	// 720h. An approved-until annotation further in the future is ignored, so the subjects are counted.
+	// +kubebuilder:validation:Pattern=`^(?:[0-9]+(?:\.[0-9]+)?(?:h|m|s|ms|us|ns))+$`
+	MaxApprovalDuration string `json:"maxApprovalDuration,omitempty"`
 
 	// low, medium, high, or critical
 	// +kubebuilder:validation:Enum=low;Low;medium;Medium;high;High;critical;Critical
 	Severity string `json:"severity,omitempty"`
 }
 
+// DefaultMaxApprovalDuration is the longest time an approval can extend into the future when the policy
+// doesn't set maxApprovalDuration
+const DefaultMaxApprovalDuration = 720 * time.Hour
+
+// GetMaxApprovalDuration returns the longest time an approval can extend into the future, or zero when the
+// approvals are not allowed.
+func (s IamPolicySpec) GetMaxApprovalDuration() (time.Duration, error) {
+	if !s.AllowApprovals {
+		return 0, nil
+	}
+
+	if s.MaxApprovalDuration == "" {
+		return DefaultMaxApprovalDuration, nil
+	}
+
+	parsed, err := time.ParseDuration(s.MaxApprovalDuration)
+	if err != nil {
+		return 0, fmt.Errorf("the maximum approval duration '%s' is not a valid duration: %w",
+			s.MaxApprovalDuration, err)
+	}
+
+	if parsed <= 0 {
+		return 0, fmt.Errorf("the maximum approval duration '%s' is not positive", s.MaxApprovalDuration)
+	}
+
+	return parsed, nil
+}
+
 type CompliancyDetail map[string][]string
 
+// RoleEvaluation is the result of evaluating the users bound to a role in a scope
+type RoleEvaluation struct {
+	// The scope of the role bindings, which is cluster-wide or a namespace
+	Scope string `json:"scope"`
+	// The role the users are bound to
+	Role string `json:"role"`
+	// The number of unique users bound to the role
+	SubjectCount int `json:"subjectCount"`
+	// The maximum number of users that can be bound to the role
+	Limit int `json:"limit"`
+	// The number of users above the limit
+	Excess int `json:"excess"`
+	// The number of unique subjects and users resolved from groups that matched ignoreSubjects
+	IgnoredSubjects int `json:"ignoredSubjects,omitempty"`
+	// The number of unique users only granted the role by cluster role bindings with an approved-until
+	// annotation that hasn't expired or by subjects of active IamPolicyExceptions, which are not counted
+	ApprovedSubjects int `json:"approvedSubjects,omitempty"`
+	// The users only granted the role by approved cluster role bindings or by subjects of active
+	// IamPolicyExceptions, which are not counted
+	ApprovedUsers []string `json:"approvedUsers,omitempty"`
+	// The names of the active IamPolicyExceptions that applied to role bindings of the role
+	AppliedExceptions []string `json:"appliedExceptions,omitempty"`
+	// The groups bound to the role whose users couldn't be resolved
+	UnresolvedGroups []string `json:"unresolvedGroups,omitempty"`
+	// Violations of the allowed and forbidden subjects
+	Violations []string `json:"violations,omitempty"`
+	// Errors encountered during the evaluation, meaning that the results may be incomplete
+	EvaluationErrors []string `json:"evaluationErrors,omitempty"`
+}
+
+// ComplianceHistoryEntry is a transition of the compliance state of the policy
+type ComplianceHistoryEntry struct {
+	// Time at which the compliance state changed
+	Timestamp metav1.Time `json:"timestamp"`
+	// The compliance state before the transition, not set for the first evaluation
+	PreviousState ComplianceState `json:"previousState,omitempty"`
+	// The compliance state after the transition
+	State ComplianceState `json:"state"`
+	// The sum of the number of users bound to each evaluated role in each scope
+	SubjectCount int `json:"subjectCount"`
+	// The reason for the compliance state
+	Message string `json:"message,omitempty"`
+}
+
+// RemovedSubject is a subject that was removed from a cluster role binding when enforcing the policy
+type RemovedSubject struct {
+	// Name of the cluster role binding the subject was removed from
+	ClusterRoleBinding string `json:"clusterRoleBinding"`
+	// Kind of the removed subject
+	Kind string `json:"kind"`
+	// Name of the removed subject
+	Name string `json:"name"`
+	// Namespace of the removed subject, only set for ServiceAccount subjects
+	Namespace string `json:"namespace,omitempty"`
+	// Time at which the subject was removed
+	RemovalTime metav1.Time `json:"removalTime"`
+}
+
 // IamPolicyStatus defines the observed state of IamPolicy
 type IamPolicyStatus struct {
 	// Compliant, NonCompliant, UnknownCompliancy
 	ComplianceState ComplianceState `json:"compliant,omitempty"`
-	// reason for non-compliancy
+	// reason for non-compliancy, derived from the evaluations
 	CompliancyDetails map[string]CompliancyDetail `json:"compliancyDetails,omitempty"`
+	// The result of evaluating each role in each scope, from which the compliance is determined
+	// +listType=map
+	// +listMapKey=scope
+	// +listMapKey=role
+	Evaluations []RoleEvaluation `json:"evaluations,omitempty"`
+	// Subjects removed from cluster role bindings the last time the policy was enforced
+	RemovedSubjects []RemovedSubject `json:"removedSubjects,omitempty"`
+	// The most recent compliance state transitions, newest first
+	ComplianceHistory []ComplianceHistoryEntry `json:"complianceHistory,omitempty"`
+	// The generation of the policy that was last evaluated
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The Compliant, EvaluationSucceeded, and Ready conditions of the policy
+	// +listType=map
+	// +listMapKey=type
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 //+kubebuilder:object:root=true
@@ -85,6 +361,7 @@ type IamPolicyStatus struct {
 // IamPolicy is the Schema for the iampolicies API
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:path=iampolicies,scope=Namespaced
+// +kubebuilder:storageversion
 type IamPolicy struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
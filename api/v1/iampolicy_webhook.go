@@ -0,0 +1,262 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// SetupWebhookWithManager registers the IamPolicy webhooks with the webhook server of the manager. This
+// includes the conversion webhook at /convert, which is served since v1 is the conversion hub, and the
+// validating webhook. The target client is used to verify that the referenced cluster roles exist on the
+// cluster the policies are evaluated on.
+func (r *IamPolicy) SetupWebhookWithManager(mgr ctrl.Manager, targetClient kubernetes.Interface) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithValidator(&iamPolicyValidator{targetClient: targetClient}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-policy-open-cluster-management-io-v1-iampolicy,mutating=false,failurePolicy=fail,sideEffects=None,groups=policy.open-cluster-management.io,resources=iampolicies,verbs=create;update,versions=v1,name=iampolicy.policy.open-cluster-management.io,admissionReviewVersions=v1
+
+// iamPolicyValidator rejects the IamPolicies with settings that would fail or be ignored at evaluation time
+type iamPolicyValidator struct {
+	targetClient kubernetes.Interface
+}
+
+var _ admission.CustomValidator = &iamPolicyValidator{}
+
+// ValidateCreate validates the IamPolicy on creation.
+func (v *iamPolicyValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return v.validate(ctx, obj)
+}
+
+// ValidateUpdate validates the IamPolicy on update.
+func (v *iamPolicyValidator) ValidateUpdate(
+	ctx context.Context, _ runtime.Object, newObj runtime.Object,
+) (admission.Warnings, error) {
+	return v.validate(ctx, newObj)
+}
+
+// ValidateDelete allows the IamPolicy to always be deleted.
+func (v *iamPolicyValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *iamPolicyValidator) validate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	policy, ok := obj.(*IamPolicy)
+	if !ok {
+		return nil, fmt.Errorf("expected an IamPolicy but got a %T", obj)
+	}
+
+	errs := validateIamPolicySpec(&policy.Spec, field.NewPath("spec"))
+	if len(errs) != 0 {
+		return nil, k8serrors.NewInvalid(GroupVersion.WithKind("IamPolicy").GroupKind(), policy.Name, errs)
+	}
+
+	return append(getSpecWarnings(&policy.Spec), v.getClusterRoleWarnings(ctx, &policy.Spec)...), nil
+}
+
+// getSpecWarnings returns a warning for each setting that the controller ignores. These are not errors so that
+// the policies accepted before they were validated can still be updated.
+func getSpecWarnings(spec *IamPolicySpec) admission.Warnings {
+	var warnings admission.Warnings
+
+	// An omitted limit is 0, and enforcing it would remove every subject of the cluster role bindings
+	if strings.EqualFold(string(spec.RemediationAction), string(Enforce)) &&
+		len(spec.ClusterRoles) == 0 && spec.MaxClusterRoleBindingUsers == 0 {
+		warnings = append(warnings, "The policy is not enforced since maxClusterRoleBindingUsers is not set")
+	}
+
+	if len(spec.AllowedServiceAccounts) != 0 && !spec.IncludeServiceAccounts {
+		warnings = append(warnings, "allowedServiceAccounts has no effect unless includeServiceAccounts is true")
+	}
+
+	return warnings
+}
+
+// getClusterRoleWarnings returns a warning for each referenced cluster role that doesn't exist on the target
+// cluster. This is not an error since the cluster role may be created after the policy.
+func (v *iamPolicyValidator) getClusterRoleWarnings(ctx context.Context, spec *IamPolicySpec) admission.Warnings {
+	if v.targetClient == nil || len(spec.PrivilegedRules) != 0 {
+		return nil
+	}
+
+	clusterRoles := make([]string, 0, len(spec.ClusterRoles))
+
+	if len(spec.ClusterRoles) == 0 {
+		if spec.ClusterRole != "" {
+			clusterRoles = append(clusterRoles, spec.ClusterRole)
+		}
+	} else {
+		for _, limit := range spec.ClusterRoles {
+			clusterRoles = append(clusterRoles, limit.Name)
+		}
+	}
+
+	var warnings admission.Warnings
+
+	for _, clusterRole := range clusterRoles {
+		_, err := v.targetClient.RbacV1().ClusterRoles().Get(ctx, clusterRole, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			warnings = append(warnings, fmt.Sprintf("The %s cluster role does not exist on the cluster", clusterRole))
+		}
+	}
+
+	return warnings
+}
+
+// validateIamPolicySpec returns the errors of the settings that would fail at evaluation time, such as
+// regexes that don't compile, and of the settings that contradict each other.
+func validateIamPolicySpec(spec *IamPolicySpec, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	errs = append(errs, validateRegexes(spec.IgnoreClusterRoleBindings, path.Child("ignoreClusterRoleBindings"))...)
+
+	forbiddenPath := path.Child("forbiddenSubjects")
+	errs = append(errs, validateRegexes(spec.ForbiddenSubjects, forbiddenPath)...)
+
+	for i, subject := range spec.IgnoreSubjects {
+		if _, err := regexp.Compile(string(subject.Name)); err != nil {
+			namePath := path.Child("ignoreSubjects").Index(i).Child("name")
+			errs = append(errs, field.Invalid(namePath, subject.Name, err.Error()))
+		}
+	}
+
+	if len(spec.LabelSelectorExpressions) != 0 {
+		_, err := metav1.LabelSelectorAsSelector(
+			&metav1.LabelSelector{MatchExpressions: spec.LabelSelectorExpressions},
+		)
+		if err != nil {
+			errs = append(errs, field.Invalid(
+				path.Child("labelSelectorExpressions"), spec.LabelSelectorExpressions, err.Error(),
+			))
+		}
+	}
+
+	if len(spec.PrivilegedRules) != 0 {
+		if spec.ClusterRole != "" {
+			errs = append(errs, field.Forbidden(
+				path.Child("clusterRole"), "clusterRole can't be set when privilegedRules is set",
+			))
+		}
+
+		if len(spec.ClusterRoles) != 0 {
+			errs = append(errs, field.Forbidden(
+				path.Child("clusterRoles"), "clusterRoles can't be set when privilegedRules is set",
+			))
+		}
+	} else if len(spec.ClusterRoles) != 0 {
+		if spec.ClusterRole != "" {
+			errs = append(errs, field.Forbidden(
+				path.Child("clusterRole"), "clusterRole can't be set when clusterRoles is set",
+			))
+		}
+
+		if spec.MaxClusterRoleBindingUsers != 0 {
+			errs = append(errs, field.Forbidden(
+				path.Child("maxClusterRoleBindingUsers"),
+				"maxClusterRoleBindingUsers can't be set when clusterRoles is set, set maxUsers instead",
+			))
+		}
+	}
+
+	seenClusterRoles := make(map[string]bool, len(spec.ClusterRoles))
+
+	for i, limit := range spec.ClusterRoles {
+		if seenClusterRoles[limit.Name] {
+			errs = append(errs, field.Duplicate(path.Child("clusterRoles").Index(i).Child("name"), limit.Name))
+		}
+
+		seenClusterRoles[limit.Name] = true
+	}
+
+	for i, include := range spec.NamespaceSelector.Include {
+		for _, exclude := range spec.NamespaceSelector.Exclude {
+			if include == exclude {
+				errs = append(errs, field.Invalid(
+					path.Child("namespaceSelector", "include").Index(i), include, "the value is also excluded",
+				))
+			}
+		}
+	}
+
+	if _, err := spec.EvaluationInterval.GetCompliantInterval(); err != nil && !errors.Is(err, ErrIsNever) {
+		errs = append(errs, field.Invalid(
+			path.Child("evaluationInterval", "compliant"), spec.EvaluationInterval.Compliant, err.Error(),
+		))
+	}
+
+	if _, err := spec.EvaluationInterval.GetNonCompliantInterval(); err != nil && !errors.Is(err, ErrIsNever) {
+		errs = append(errs, field.Invalid(
+			path.Child("evaluationInterval", "noncompliant"), spec.EvaluationInterval.NonCompliant, err.Error(),
+		))
+	}
+
+	if _, err := spec.GetMaxApprovalDuration(); err != nil {
+		errs = append(errs, field.Invalid(path.Child("maxApprovalDuration"), spec.MaxApprovalDuration, err.Error()))
+	}
+
+	errs = append(errs, validateAllowedNotForbidden(spec, path)...)
+
+	return errs
+}
+
+// validateRegexes returns an error for each value that doesn't compile as a regex.
+func validateRegexes(values []NonEmptyString, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	for i, value := range values {
+		if _, err := regexp.Compile(string(value)); err != nil {
+			errs = append(errs, field.Invalid(path.Index(i), value, err.Error()))
+		}
+	}
+
+	return errs
+}
+
+// validateAllowedNotForbidden returns an error for each allowed user or group that is also matched by a
+// forbidden subject, since it would be allowed and forbidden at the same time.
+func validateAllowedNotForbidden(spec *IamPolicySpec, path *field.Path) field.ErrorList {
+	compiledForbidden := make([]*regexp.Regexp, 0, len(spec.ForbiddenSubjects))
+
+	for _, forbidden := range spec.ForbiddenSubjects {
+		// The invalid regexes are already reported by validateRegexes
+		if regex, err := regexp.Compile(string(forbidden)); err == nil {
+			compiledForbidden = append(compiledForbidden, regex)
+		}
+	}
+
+	var errs field.ErrorList
+
+	validateAllowed := func(allowed []NonEmptyString, allowedPath *field.Path) {
+		for i, name := range allowed {
+			for _, regex := range compiledForbidden {
+				if regex.MatchString(string(name)) {
+					errs = append(errs, field.Invalid(
+						allowedPath.Index(i), name, fmt.Sprintf("the value is forbidden by %s", regex),
+					))
+
+					break
+				}
+			}
+		}
+	}
+
+	validateAllowed(spec.AllowedUsers, path.Child("allowedUsers"))
+	validateAllowed(spec.AllowedGroups, path.Child("allowedGroups"))
+
+	return errs
+}
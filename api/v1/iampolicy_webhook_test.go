@@ -0,0 +1,201 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateIamPolicySpec(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		spec   IamPolicySpec
+		fields []string
+	}{
+		"valid": {
+			spec: IamPolicySpec{
+				ClusterRole:                "admin",
+				MaxClusterRoleBindingUsers: 2,
+				IgnoreClusterRoleBindings:  []NonEmptyString{"^system:"},
+				ForbiddenSubjects:          []NonEmptyString{"^guest-"},
+				AllowedUsers:               []NonEmptyString{"alice"},
+			},
+		},
+		"invalid regexes": {
+			spec: IamPolicySpec{
+				IgnoreClusterRoleBindings: []NonEmptyString{"^system:", "foo("},
+				ForbiddenSubjects:         []NonEmptyString{"[a-"},
+				IgnoreSubjects:            []IgnoredSubject{{Kind: "User", Name: "^ops-"}, {Kind: "Group", Name: "*"}},
+			},
+			fields: []string{
+				"spec.ignoreClusterRoleBindings[1]", "spec.forbiddenSubjects[0]", "spec.ignoreSubjects[1].name",
+			},
+		},
+		"invalid label selector expression": {
+			spec: IamPolicySpec{
+				LabelSelectorExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "env", Operator: metav1.LabelSelectorOpIn},
+				},
+			},
+			fields: []string{"spec.labelSelectorExpressions"},
+		},
+		"privileged rules with cluster roles": {
+			spec: IamPolicySpec{
+				ClusterRole:     "admin",
+				ClusterRoles:    []ClusterRoleLimit{{Name: "edit", MaxUsers: 1}},
+				PrivilegedRules: []PrivilegedRule{{Resources: []string{"secrets"}, Verbs: []string{"get"}}},
+			},
+			fields: []string{"spec.clusterRole", "spec.clusterRoles"},
+		},
+		"cluster roles with legacy fields": {
+			spec: IamPolicySpec{
+				ClusterRole:                "admin",
+				MaxClusterRoleBindingUsers: 2,
+				ClusterRoles: []ClusterRoleLimit{
+					{Name: "edit", MaxUsers: 1},
+					{Name: "edit", MaxUsers: 3},
+				},
+			},
+			fields: []string{"spec.clusterRole", "spec.maxClusterRoleBindingUsers", "spec.clusterRoles[1].name"},
+		},
+		"included and excluded namespace": {
+			spec: IamPolicySpec{
+				NamespaceSelector: Target{
+					Include: []NonEmptyString{"default", "kube-*"},
+					Exclude: []NonEmptyString{"kube-*"},
+				},
+			},
+			fields: []string{"spec.namespaceSelector.include[1]"},
+		},
+		"allowed and forbidden subjects": {
+			spec: IamPolicySpec{
+				AllowedUsers:      []NonEmptyString{"alice", "guest-bob"},
+				AllowedGroups:     []NonEmptyString{"guest-team"},
+				ForbiddenSubjects: []NonEmptyString{"^guest-"},
+			},
+			fields: []string{"spec.allowedUsers[1]", "spec.allowedGroups[0]"},
+		},
+		"invalid evaluation interval": {
+			spec: IamPolicySpec{
+				EvaluationInterval: EvaluationInterval{Compliant: "never", NonCompliant: "soon"},
+			},
+			fields: []string{"spec.evaluationInterval.noncompliant"},
+		},
+		"invalid maximum approval duration": {
+			spec:   IamPolicySpec{AllowApprovals: true, MaxApprovalDuration: "0s"},
+			fields: []string{"spec.maxApprovalDuration"},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			errs := validateIamPolicySpec(&test.spec, field.NewPath("spec"))
+
+			fields := make([]string, 0, len(errs))
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+
+			assert.ElementsMatch(t, test.fields, fields)
+		})
+	}
+}
+
+func TestGetSpecWarnings(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		spec     IamPolicySpec
+		warnings []string
+	}{
+		"enforced with a limit": {
+			spec: IamPolicySpec{RemediationAction: Enforce, MaxClusterRoleBindingUsers: 2},
+		},
+		"enforced without a limit": {
+			spec: IamPolicySpec{
+				RemediationAction: "enforce",
+				PrivilegedRules:   []PrivilegedRule{{Resources: []string{"secrets"}, Verbs: []string{"get"}}},
+			},
+			warnings: []string{"The policy is not enforced since maxClusterRoleBindingUsers is not set"},
+		},
+		"enforced with cluster role limits": {
+			spec: IamPolicySpec{
+				RemediationAction: Enforce,
+				ClusterRoles:      []ClusterRoleLimit{{Name: "edit", MaxUsers: 1}},
+			},
+		},
+		"informed without a limit": {
+			spec: IamPolicySpec{RemediationAction: Inform},
+		},
+		"allowed service accounts without service accounts": {
+			spec: IamPolicySpec{
+				AllowedServiceAccounts: []NonEmptyString{"default:builder"},
+			},
+			warnings: []string{"allowedServiceAccounts has no effect unless includeServiceAccounts is true"},
+		},
+		"allowed service accounts with service accounts": {
+			spec: IamPolicySpec{
+				IncludeServiceAccounts: true,
+				AllowedServiceAccounts: []NonEmptyString{"default:builder"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.ElementsMatch(t, test.warnings, getSpecWarnings(&test.spec))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	validator := &iamPolicyValidator{
+		targetClient: fake.NewSimpleClientset(
+			&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "admin"}},
+		),
+	}
+
+	policy := &IamPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "managed"},
+		Spec: IamPolicySpec{
+			ClusterRoles: []ClusterRoleLimit{
+				{Name: "admin", MaxUsers: 2},
+				{Name: "missing", MaxUsers: 2},
+			},
+		},
+	}
+
+	warnings, err := validator.ValidateCreate(context.TODO(), policy)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"The missing cluster role does not exist on the cluster"}, []string(warnings))
+
+	invalid := policy.DeepCopy()
+	invalid.Spec.IgnoreClusterRoleBindings = []NonEmptyString{"foo("}
+
+	warnings, err = validator.ValidateUpdate(context.TODO(), policy, invalid)
+	assert.True(t, errors.IsInvalid(err))
+	assert.Empty(t, warnings)
+
+	warnings, err = validator.ValidateDelete(context.TODO(), invalid)
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+}
@@ -0,0 +1,93 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ExceptionSubject is a subject of a role binding that is excepted from an IamPolicy
+type ExceptionSubject struct {
+	// Kind of the subject
+	// +kubebuilder:validation:Enum=User;Group;ServiceAccount
+	Kind string `json:"kind"`
+	// Name of the subject, which is in the <namespace>:<name> format for a ServiceAccount. A Group
+	// exception applies to all the users of the group.
+	Name NonEmptyString `json:"name"`
+}
+
+// IamPolicyExceptionSpec defines an approved deviation from an IamPolicy in the same namespace. At least
+// one of subject or clusterRoleBinding must be set, and when both are set, the exception only applies to
+// the subject in that cluster role binding.
+type IamPolicyExceptionSpec struct {
+	// The name of the IamPolicy in the same namespace the exception applies to
+	// +kubebuilder:validation:MinLength=1
+	PolicyName string `json:"policyName"`
+	// The subject whose role bindings are not counted against the limits and are not violations of the
+	// allowed and forbidden subjects
+	Subject *ExceptionSubject `json:"subject,omitempty"`
+	// The name of the cluster role binding whose subjects are not counted against the limits and are not
+	// violations of the allowed and forbidden subjects
+	ClusterRoleBinding string `json:"clusterRoleBinding,omitempty"`
+	// Why the deviation from the policy is acceptable
+	// +kubebuilder:validation:MinLength=1
+	Justification string `json:"justification"`
+	// Who approved the exception
+	// +kubebuilder:validation:MinLength=1
+	Approver string `json:"approver"`
+	// The time after which the exception no longer applies
+	Expires metav1.Time `json:"expires"`
+}
+
+// IsActive returns whether the exception applies at the given time.
+func (e *IamPolicyException) IsActive(now time.Time) bool {
+	return now.Before(e.Spec.Expires.Time)
+}
+
+// Matches returns whether the exception applies to the subject in the role binding. The name of a
+// ServiceAccount subject must be in the <namespace>:<name> format. The name of a RoleBinding never matches
+// the clusterRoleBinding field.
+func (e *IamPolicyException) Matches(clusterRoleBinding string, kind string, name string) bool {
+	if e.Spec.Subject == nil && e.Spec.ClusterRoleBinding == "" {
+		return false
+	}
+
+	if e.Spec.ClusterRoleBinding != "" && e.Spec.ClusterRoleBinding != clusterRoleBinding {
+		return false
+	}
+
+	if e.Spec.Subject != nil && (e.Spec.Subject.Kind != kind || string(e.Spec.Subject.Name) != name) {
+		return false
+	}
+
+	return true
+}
+
+//+kubebuilder:object:root=true
+
+// IamPolicyException is the Schema for the iampolicyexceptions API
+// +kubebuilder:resource:path=iampolicyexceptions,scope=Namespaced
+// +kubebuilder:printcolumn:name="Policy",type="string",JSONPath=".spec.policyName"
+// +kubebuilder:printcolumn:name="Approver",type="string",JSONPath=".spec.approver"
+// +kubebuilder:printcolumn:name="Expires",type="string",format="date-time",JSONPath=".spec.expires"
+type IamPolicyException struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec IamPolicyExceptionSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// IamPolicyExceptionList contains a list of IamPolicyException
+type IamPolicyExceptionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IamPolicyException `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IamPolicyException{}, &IamPolicyExceptionList{})
+}
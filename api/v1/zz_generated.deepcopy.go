@@ -9,9 +9,41 @@
 package v1
 
 import (
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterRoleLimit) DeepCopyInto(out *ClusterRoleLimit) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterRoleLimit.
+func (in *ClusterRoleLimit) DeepCopy() *ClusterRoleLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterRoleLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComplianceHistoryEntry) DeepCopyInto(out *ComplianceHistoryEntry) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComplianceHistoryEntry.
+func (in *ComplianceHistoryEntry) DeepCopy() *ComplianceHistoryEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(ComplianceHistoryEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in CompliancyDetail) DeepCopyInto(out *CompliancyDetail) {
 	{
@@ -41,6 +73,36 @@ func (in CompliancyDetail) DeepCopy() CompliancyDetail {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EvaluationInterval) DeepCopyInto(out *EvaluationInterval) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EvaluationInterval.
+func (in *EvaluationInterval) DeepCopy() *EvaluationInterval {
+	if in == nil {
+		return nil
+	}
+	out := new(EvaluationInterval)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExceptionSubject) DeepCopyInto(out *ExceptionSubject) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExceptionSubject.
+func (in *ExceptionSubject) DeepCopy() *ExceptionSubject {
+	if in == nil {
+		return nil
+	}
+	out := new(ExceptionSubject)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IamPolicy) DeepCopyInto(out *IamPolicy) {
 	*out = *in
@@ -68,6 +130,85 @@ func (in *IamPolicy) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IamPolicyException) DeepCopyInto(out *IamPolicyException) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IamPolicyException.
+func (in *IamPolicyException) DeepCopy() *IamPolicyException {
+	if in == nil {
+		return nil
+	}
+	out := new(IamPolicyException)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IamPolicyException) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IamPolicyExceptionList) DeepCopyInto(out *IamPolicyExceptionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]IamPolicyException, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IamPolicyExceptionList.
+func (in *IamPolicyExceptionList) DeepCopy() *IamPolicyExceptionList {
+	if in == nil {
+		return nil
+	}
+	out := new(IamPolicyExceptionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IamPolicyExceptionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IamPolicyExceptionSpec) DeepCopyInto(out *IamPolicyExceptionSpec) {
+	*out = *in
+	if in.Subject != nil {
+		in, out := &in.Subject, &out.Subject
+		*out = new(ExceptionSubject)
+		**out = **in
+	}
+	in.Expires.DeepCopyInto(&out.Expires)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IamPolicyExceptionSpec.
+func (in *IamPolicyExceptionSpec) DeepCopy() *IamPolicyExceptionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(IamPolicyExceptionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IamPolicyList) DeepCopyInto(out *IamPolicyList) {
 	*out = *in
@@ -108,6 +249,11 @@ func (in *IamPolicySpec) DeepCopyInto(out *IamPolicySpec) {
 		*out = make([]NonEmptyString, len(*in))
 		copy(*out, *in)
 	}
+	if in.IgnoreSubjects != nil {
+		in, out := &in.IgnoreSubjects, &out.IgnoreSubjects
+		*out = make([]IgnoredSubject, len(*in))
+		copy(*out, *in)
+	}
 	in.NamespaceSelector.DeepCopyInto(&out.NamespaceSelector)
 	if in.LabelSelector != nil {
 		in, out := &in.LabelSelector, &out.LabelSelector
@@ -116,6 +262,51 @@ func (in *IamPolicySpec) DeepCopyInto(out *IamPolicySpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.LabelSelectorExpressions != nil {
+		in, out := &in.LabelSelectorExpressions, &out.LabelSelectorExpressions
+		*out = make([]metav1.LabelSelectorRequirement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ClusterRoles != nil {
+		in, out := &in.ClusterRoles, &out.ClusterRoles
+		*out = make([]ClusterRoleLimit, len(*in))
+		copy(*out, *in)
+	}
+	if in.PrivilegedRules != nil {
+		in, out := &in.PrivilegedRules, &out.PrivilegedRules
+		*out = make([]PrivilegedRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AllowedUsers != nil {
+		in, out := &in.AllowedUsers, &out.AllowedUsers
+		*out = make([]NonEmptyString, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedGroups != nil {
+		in, out := &in.AllowedGroups, &out.AllowedGroups
+		*out = make([]NonEmptyString, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedServiceAccounts != nil {
+		in, out := &in.AllowedServiceAccounts, &out.AllowedServiceAccounts
+		*out = make([]NonEmptyString, len(*in))
+		copy(*out, *in)
+	}
+	if in.ForbiddenSubjects != nil {
+		in, out := &in.ForbiddenSubjects, &out.ForbiddenSubjects
+		*out = make([]NonEmptyString, len(*in))
+		copy(*out, *in)
+	}
+	if in.ComplianceHistoryLimit != nil {
+		in, out := &in.ComplianceHistoryLimit, &out.ComplianceHistoryLimit
+		*out = new(int)
+		**out = **in
+	}
+	out.EvaluationInterval = in.EvaluationInterval
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IamPolicySpec.
@@ -156,6 +347,34 @@ func (in *IamPolicyStatus) DeepCopyInto(out *IamPolicyStatus) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.Evaluations != nil {
+		in, out := &in.Evaluations, &out.Evaluations
+		*out = make([]RoleEvaluation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RemovedSubjects != nil {
+		in, out := &in.RemovedSubjects, &out.RemovedSubjects
+		*out = make([]RemovedSubject, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ComplianceHistory != nil {
+		in, out := &in.ComplianceHistory, &out.ComplianceHistory
+		*out = make([]ComplianceHistoryEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IamPolicyStatus.
@@ -168,6 +387,107 @@ func (in *IamPolicyStatus) DeepCopy() *IamPolicyStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IgnoredSubject) DeepCopyInto(out *IgnoredSubject) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnoredSubject.
+func (in *IgnoredSubject) DeepCopy() *IgnoredSubject {
+	if in == nil {
+		return nil
+	}
+	out := new(IgnoredSubject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrivilegedRule) DeepCopyInto(out *PrivilegedRule) {
+	*out = *in
+	if in.APIGroups != nil {
+		in, out := &in.APIGroups, &out.APIGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Verbs != nil {
+		in, out := &in.Verbs, &out.Verbs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrivilegedRule.
+func (in *PrivilegedRule) DeepCopy() *PrivilegedRule {
+	if in == nil {
+		return nil
+	}
+	out := new(PrivilegedRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemovedSubject) DeepCopyInto(out *RemovedSubject) {
+	*out = *in
+	in.RemovalTime.DeepCopyInto(&out.RemovalTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemovedSubject.
+func (in *RemovedSubject) DeepCopy() *RemovedSubject {
+	if in == nil {
+		return nil
+	}
+	out := new(RemovedSubject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RoleEvaluation) DeepCopyInto(out *RoleEvaluation) {
+	*out = *in
+	if in.ApprovedUsers != nil {
+		in, out := &in.ApprovedUsers, &out.ApprovedUsers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AppliedExceptions != nil {
+		in, out := &in.AppliedExceptions, &out.AppliedExceptions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UnresolvedGroups != nil {
+		in, out := &in.UnresolvedGroups, &out.UnresolvedGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Violations != nil {
+		in, out := &in.Violations, &out.Violations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.EvaluationErrors != nil {
+		in, out := &in.EvaluationErrors, &out.EvaluationErrors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RoleEvaluation.
+func (in *RoleEvaluation) DeepCopy() *RoleEvaluation {
+	if in == nil {
+		return nil
+	}
+	out := new(RoleEvaluation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Target) DeepCopyInto(out *Target) {
 	*out = *in
@@ -0,0 +1,261 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	v1 "open-cluster-management.io/iam-policy-controller/api/v1"
+)
+
+const (
+	// defaultClusterRole is the cluster role evaluated by a v1 IamPolicy that doesn't set one
+	defaultClusterRole = "cluster-admin"
+
+	// legacyClusterRoleAnnotation records the clusterRole of a v1 IamPolicy whose limit is set with the clusterRole
+	// and maxClusterRoleBindingUsers fields, so that converting it back to v1 restores these fields
+	legacyClusterRoleAnnotation = "policy.open-cluster-management.io/v1-cluster-role"
+)
+
+// ConvertTo converts this IamPolicy to the v1 hub version. The cluster role limits are kept in clusterRoles,
+// unless the policy was converted from the clusterRole and maxClusterRoleBindingUsers fields of v1.
+func (src *IamPolicy) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1.IamPolicy)
+
+	dst.ObjectMeta = src.ObjectMeta
+
+	legacyClusterRole, fromLegacyFields := src.Annotations[legacyClusterRoleAnnotation]
+	if fromLegacyFields {
+		dst.Annotations = withoutAnnotation(src.Annotations, legacyClusterRoleAnnotation)
+	}
+
+	dst.Spec = v1.IamPolicySpec{
+		Severity:                  string(src.Spec.Severity),
+		RemediationAction:         v1.RemediationAction(src.Spec.RemediationAction),
+		IgnoreClusterRoleBindings: convertStrings[v1.NonEmptyString](src.Spec.IgnoreClusterRoleBindings),
+		IgnoreSubjects: convertSlice(src.Spec.IgnoreSubjects, func(subject IgnoredSubject) v1.IgnoredSubject {
+			return v1.IgnoredSubject{Kind: subject.Kind, Name: v1.NonEmptyString(subject.Name)}
+		}),
+		NamespaceSelector: v1.Target{
+			Include: convertStrings[v1.NonEmptyString](src.Spec.NamespaceSelector.Include),
+			Exclude: convertStrings[v1.NonEmptyString](src.Spec.NamespaceSelector.Exclude),
+		},
+		IncludeServiceAccounts: src.Spec.IncludeServiceAccounts,
+		AllowedUsers:           convertStrings[v1.NonEmptyString](src.Spec.AllowedUsers),
+		AllowedGroups:          convertStrings[v1.NonEmptyString](src.Spec.AllowedGroups),
+		AllowedServiceAccounts: convertStrings[v1.NonEmptyString](src.Spec.AllowedServiceAccounts),
+		ForbiddenSubjects:      convertStrings[v1.NonEmptyString](src.Spec.ForbiddenSubjects),
+		ComplianceHistoryLimit: src.Spec.ComplianceHistoryLimit,
+		EvaluationInterval:     v1.EvaluationInterval(src.Spec.EvaluationInterval),
+		UnresolvableGroups:     v1.UnresolvableGroupsMode(src.Spec.UnresolvableGroups),
+		AllowApprovals:         src.Spec.AllowApprovals,
+		MaxApprovalDuration:    src.Spec.MaxApprovalDuration,
+	}
+
+	if selector := src.Spec.ClusterRoleBindingSelector; selector != nil {
+		dst.Spec.LabelSelector = selector.MatchLabels
+		dst.Spec.LabelSelectorExpressions = selector.MatchExpressions
+	}
+
+	clusterRoles := convertSlice(src.Spec.ClusterRoles, func(limit ClusterRoleLimit) v1.ClusterRoleLimit {
+		return v1.ClusterRoleLimit(limit)
+	})
+
+	switch {
+	case src.Spec.PrivilegedRoles != nil:
+		rules := src.Spec.PrivilegedRoles.Rules
+
+		dst.Spec.PrivilegedRules = convertSlice(rules, func(rule PrivilegedRule) v1.PrivilegedRule {
+			return v1.PrivilegedRule(rule)
+		})
+		dst.Spec.MaxClusterRoleBindingUsers = src.Spec.PrivilegedRoles.MaxUsers
+		dst.Spec.ClusterRoles = clusterRoles
+	case len(clusterRoles) == 1 && fromLegacyFields:
+		// An omitted clusterRole stays omitted as long as the default cluster role is evaluated
+		if legacyClusterRole != "" || clusterRoles[0].Name != defaultClusterRole {
+			dst.Spec.ClusterRole = clusterRoles[0].Name
+		}
+
+		dst.Spec.MaxClusterRoleBindingUsers = clusterRoles[0].MaxUsers
+	default:
+		dst.Spec.ClusterRoles = clusterRoles
+	}
+
+	dst.Status = v1.IamPolicyStatus{
+		ComplianceState:    v1.ComplianceState(src.Status.ComplianceState),
+		Conditions:         src.Status.Conditions,
+		ObservedGeneration: src.Status.ObservedGeneration,
+		Evaluations: convertSlice(src.Status.Evaluations, func(evaluation RoleEvaluation) v1.RoleEvaluation {
+			return v1.RoleEvaluation(evaluation)
+		}),
+		RemovedSubjects: convertSlice(src.Status.RemovedSubjects, func(subject RemovedSubject) v1.RemovedSubject {
+			return v1.RemovedSubject(subject)
+		}),
+		ComplianceHistory: convertSlice(
+			src.Status.ComplianceHistory,
+			func(entry ComplianceHistoryEntry) v1.ComplianceHistoryEntry {
+				return v1.ComplianceHistoryEntry{
+					Timestamp:     entry.Timestamp,
+					PreviousState: v1.ComplianceState(entry.PreviousState),
+					State:         v1.ComplianceState(entry.State),
+					SubjectCount:  entry.SubjectCount,
+					Message:       entry.Message,
+				}
+			},
+		),
+	}
+
+	return nil
+}
+
+// ConvertFrom converts the v1 hub version to this IamPolicy. The compliancy details are not converted since
+// they are derived from the evaluations. The clusterRole and maxClusterRoleBindingUsers fields are converted to
+// a single cluster role limit, and the legacyClusterRoleAnnotation records that they were set.
+func (dst *IamPolicy) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1.IamPolicy)
+
+	dst.ObjectMeta = src.ObjectMeta
+
+	dst.Spec = IamPolicySpec{
+		Severity:                  Severity(strings.ToLower(src.Spec.Severity)),
+		IgnoreClusterRoleBindings: convertStrings[NonEmptyString](src.Spec.IgnoreClusterRoleBindings),
+		IgnoreSubjects: convertSlice(src.Spec.IgnoreSubjects, func(subject v1.IgnoredSubject) IgnoredSubject {
+			return IgnoredSubject{Kind: subject.Kind, Name: NonEmptyString(subject.Name)}
+		}),
+		NamespaceSelector: NamespaceSelector{
+			Include: convertStrings[NonEmptyString](src.Spec.NamespaceSelector.Include),
+			Exclude: convertStrings[NonEmptyString](src.Spec.NamespaceSelector.Exclude),
+		},
+		IncludeServiceAccounts: src.Spec.IncludeServiceAccounts,
+		AllowedUsers:           convertStrings[NonEmptyString](src.Spec.AllowedUsers),
+		AllowedGroups:          convertStrings[NonEmptyString](src.Spec.AllowedGroups),
+		AllowedServiceAccounts: convertStrings[NonEmptyString](src.Spec.AllowedServiceAccounts),
+		ForbiddenSubjects:      convertStrings[NonEmptyString](src.Spec.ForbiddenSubjects),
+		ComplianceHistoryLimit: src.Spec.ComplianceHistoryLimit,
+		EvaluationInterval:     EvaluationInterval(src.Spec.EvaluationInterval),
+		UnresolvableGroups:     UnresolvableGroupsMode(src.Spec.UnresolvableGroups),
+		AllowApprovals:         src.Spec.AllowApprovals,
+		MaxApprovalDuration:    src.Spec.MaxApprovalDuration,
+	}
+
+	switch {
+	case strings.EqualFold(string(src.Spec.RemediationAction), string(Enforce)):
+		dst.Spec.RemediationAction = Enforce
+	case strings.EqualFold(string(src.Spec.RemediationAction), string(Inform)):
+		dst.Spec.RemediationAction = Inform
+	}
+
+	if len(src.Spec.LabelSelector) != 0 || len(src.Spec.LabelSelectorExpressions) != 0 {
+		dst.Spec.ClusterRoleBindingSelector = &metav1.LabelSelector{
+			MatchLabels:      src.Spec.LabelSelector,
+			MatchExpressions: src.Spec.LabelSelectorExpressions,
+		}
+	}
+
+	dst.Spec.ClusterRoles = convertSlice(src.Spec.ClusterRoles, func(limit v1.ClusterRoleLimit) ClusterRoleLimit {
+		return ClusterRoleLimit(limit)
+	})
+
+	switch {
+	case len(src.Spec.PrivilegedRules) != 0:
+		dst.Spec.PrivilegedRoles = &PrivilegedRoles{
+			Rules: convertSlice(src.Spec.PrivilegedRules, func(rule v1.PrivilegedRule) PrivilegedRule {
+				return PrivilegedRule(rule)
+			}),
+			MaxUsers: src.Spec.MaxClusterRoleBindingUsers,
+		}
+	case len(src.Spec.ClusterRoles) == 0 && (src.Spec.ClusterRole != "" || src.Spec.MaxClusterRoleBindingUsers != 0):
+		clusterRole := src.Spec.ClusterRole
+		if clusterRole == "" {
+			clusterRole = defaultClusterRole
+		}
+
+		dst.Spec.ClusterRoles = []ClusterRoleLimit{
+			{Name: clusterRole, MaxUsers: src.Spec.MaxClusterRoleBindingUsers},
+		}
+
+		dst.Annotations = withAnnotation(src.Annotations, legacyClusterRoleAnnotation, src.Spec.ClusterRole)
+	}
+
+	dst.Status = IamPolicyStatus{
+		ComplianceState:    ComplianceState(src.Status.ComplianceState),
+		Conditions:         src.Status.Conditions,
+		ObservedGeneration: src.Status.ObservedGeneration,
+		Evaluations: convertSlice(src.Status.Evaluations, func(evaluation v1.RoleEvaluation) RoleEvaluation {
+			return RoleEvaluation(evaluation)
+		}),
+		RemovedSubjects: convertSlice(src.Status.RemovedSubjects, func(subject v1.RemovedSubject) RemovedSubject {
+			return RemovedSubject(subject)
+		}),
+		ComplianceHistory: convertSlice(
+			src.Status.ComplianceHistory,
+			func(entry v1.ComplianceHistoryEntry) ComplianceHistoryEntry {
+				return ComplianceHistoryEntry{
+					Timestamp:     entry.Timestamp,
+					PreviousState: ComplianceState(entry.PreviousState),
+					State:         ComplianceState(entry.State),
+					SubjectCount:  entry.SubjectCount,
+					Message:       entry.Message,
+				}
+			},
+		),
+	}
+
+	return nil
+}
+
+// withAnnotation returns a copy of the annotations with the annotation set, so that the annotations of the
+// converted object are not modified.
+func withAnnotation(annotations map[string]string, key string, value string) map[string]string {
+	copied := make(map[string]string, len(annotations)+1)
+
+	for k, v := range annotations {
+		copied[k] = v
+	}
+
+	copied[key] = value
+
+	return copied
+}
+
+// withoutAnnotation returns a copy of the annotations without the annotation, or nil when no annotations are
+// left, so that the annotations of the converted object are not modified.
+func withoutAnnotation(annotations map[string]string, key string) map[string]string {
+	if len(annotations) <= 1 {
+		return nil
+	}
+
+	copied := make(map[string]string, len(annotations)-1)
+
+	for k, v := range annotations {
+		if k != key {
+			copied[k] = v
+		}
+	}
+
+	return copied
+}
+
+// convertStrings converts a slice of strings between the string types of the API versions, keeping nil
+// slices nil so that omitted fields stay omitted.
+func convertStrings[T, S ~string](values []S) []T {
+	return convertSlice(values, func(value S) T { return T(value) })
+}
+
+// convertSlice converts each item of a slice between the types of the API versions, keeping nil slices nil.
+func convertSlice[T, S any](items []S, convert func(S) T) []T {
+	if items == nil {
+		return nil
+	}
+
+	converted := make([]T, 0, len(items))
+
+	for _, item := range items {
+		converted = append(converted, convert(item))
+	}
+
+	return converted
+}
@@ -0,0 +1,183 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "open-cluster-management.io/iam-policy-controller/api/v1"
+)
+
+func TestConvertRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	historyLimit := 5
+	transitionTime := metav1.Unix(1700000000, 0)
+
+	tests := map[string]IamPolicySpec{
+		"single cluster role": {
+			Severity:          "high",
+			RemediationAction: Enforce,
+			ClusterRoles:      []ClusterRoleLimit{{Name: "admin", MaxUsers: 2}},
+			ClusterRoleBindingSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"team": "ops"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"prod"}},
+				},
+			},
+			IgnoreClusterRoleBindings: []NonEmptyString{"^system:"},
+			IgnoreSubjects:            []IgnoredSubject{{Kind: "User", Name: "^break-glass-"}},
+			NamespaceSelector:         NamespaceSelector{Include: []NonEmptyString{"*"}},
+			AllowedUsers:              []NonEmptyString{"alice"},
+			ComplianceHistoryLimit:    &historyLimit,
+			EvaluationInterval:        EvaluationInterval{Compliant: "1h", NonCompliant: "30s"},
+			UnresolvableGroups:        CountAsOne,
+			AllowApprovals:            true,
+			MaxApprovalDuration:       "72h",
+		},
+		"multiple cluster roles": {
+			RemediationAction: Inform,
+			ClusterRoles: []ClusterRoleLimit{
+				{Name: "admin", MaxUsers: 2},
+				{Name: "edit", MaxUsers: 5},
+			},
+			ForbiddenSubjects: []NonEmptyString{"^guest"},
+		},
+		"privileged roles": {
+			RemediationAction: Inform,
+			PrivilegedRoles: &PrivilegedRoles{
+				Rules: []PrivilegedRule{
+					{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+				},
+				MaxUsers: 3,
+			},
+			IncludeServiceAccounts: true,
+			AllowedServiceAccounts: []NonEmptyString{"default:builder"},
+		},
+	}
+
+	for name, spec := range tests {
+		spec := spec
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			policy := &IamPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "managed", Generation: 2},
+				Spec:       spec,
+				Status: IamPolicyStatus{
+					ComplianceState:    NonCompliant,
+					ObservedGeneration: 2,
+					Evaluations: []RoleEvaluation{
+						{
+							Scope:             "cluster-wide",
+							Role:              "admin",
+							SubjectCount:      3,
+							Limit:             2,
+							Excess:            1,
+							IgnoredSubjects:   1,
+							AppliedExceptions: []string{"migration"},
+							UnresolvedGroups:  []string{"oidc:admins"},
+						},
+					},
+					ComplianceHistory: []ComplianceHistoryEntry{
+						{Timestamp: transitionTime, PreviousState: Compliant, State: NonCompliant, SubjectCount: 3},
+					},
+				},
+			}
+
+			hub := &v1.IamPolicy{}
+			assert.NoError(t, policy.ConvertTo(hub))
+
+			converted := &IamPolicy{}
+			assert.NoError(t, converted.ConvertFrom(hub))
+
+			assert.Equal(t, policy, converted)
+		})
+	}
+}
+
+func TestConvertHubRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]v1.IamPolicySpec{
+		"single cluster role limit": {
+			RemediationAction: v1.Enforce,
+			ClusterRoles:      []v1.ClusterRoleLimit{{Name: "admin", MaxUsers: 2}},
+		},
+		"legacy cluster role": {
+			RemediationAction:          v1.Inform,
+			ClusterRole:                "admin",
+			MaxClusterRoleBindingUsers: 2,
+		},
+		"legacy default cluster role": {
+			RemediationAction:          v1.Inform,
+			MaxClusterRoleBindingUsers: 2,
+		},
+		"legacy cluster-admin cluster role": {
+			RemediationAction:          v1.Inform,
+			ClusterRole:                "cluster-admin",
+			MaxClusterRoleBindingUsers: 2,
+		},
+	}
+
+	for name, spec := range tests {
+		spec := spec
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			hub := &v1.IamPolicy{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "policy", Namespace: "managed", Annotations: map[string]string{"team": "ops"},
+				},
+				Spec: spec,
+			}
+
+			policy := &IamPolicy{}
+			assert.NoError(t, policy.ConvertFrom(hub))
+
+			converted := &v1.IamPolicy{}
+			assert.NoError(t, policy.ConvertTo(converted))
+
+			assert.Equal(t, hub, converted)
+		})
+	}
+}
+
+func TestConvertFromLegacyFields(t *testing.T) {
+	t.Parallel()
+
+	hub := &v1.IamPolicy{
+		Spec: v1.IamPolicySpec{
+			Severity:                   "High",
+			RemediationAction:          "enforce",
+			MaxClusterRoleBindingUsers: 4,
+			LabelSelector:              map[string]string{"team": "ops"},
+		},
+		Status: v1.IamPolicyStatus{
+			ComplianceState: v1.NonCompliant,
+			CompliancyDetails: map[string]v1.CompliancyDetail{
+				"cluster-wide": {
+					"violations": []string{"The number of users with the cluster-admin role is at least 5"},
+				},
+			},
+		},
+	}
+
+	policy := &IamPolicy{}
+	assert.NoError(t, policy.ConvertFrom(hub))
+
+	assert.Equal(t, Severity("high"), policy.Spec.Severity)
+	assert.Equal(t, Enforce, policy.Spec.RemediationAction)
+	assert.Equal(t, []ClusterRoleLimit{{Name: "cluster-admin", MaxUsers: 4}}, policy.Spec.ClusterRoles)
+	assert.Equal(t, map[string]string{legacyClusterRoleAnnotation: ""}, policy.Annotations)
+	assert.Nil(t, hub.Annotations)
+	assert.Equal(
+		t, &metav1.LabelSelector{MatchLabels: map[string]string{"team": "ops"}}, policy.Spec.ClusterRoleBindingSelector,
+	)
+	assert.Equal(t, NonCompliant, policy.Status.ComplianceState)
+}
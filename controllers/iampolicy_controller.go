@@ -10,100 +10,191 @@ package controllers
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
+	"net/http"
 	"regexp"
-	"strconv"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	policiesv1 "open-cluster-management.io/governance-policy-propagator/api/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	iampolicyv1 "open-cluster-management.io/iam-policy-controller/api/v1"
 	"open-cluster-management.io/iam-policy-controller/pkg/common"
+	"open-cluster-management.io/iam-policy-controller/pkg/groups"
 )
 
 const (
 	// Format string taking the role name and the user count to create the violation message
 	violationMsgF = "The number of users with the %s role is at least %s above the specified limit"
-	// Format string taking the role name to make the regex to extract the usercount from the violation message
-	// Reminder: always `regexp.QuoteMeta` the input here.
-	violationMsgFUserCountRegex = `^(?:The number of users with the %s role is at least )` +
-		`(\d+)(?: above the specified limit)$`
+	// Format string taking the role name and the error to create the evaluation error message
+	evaluationErrMsgF = "The %s role could not be fully evaluated: %s"
+	// Format string taking the role name and the comma separated users that are not in the allowed lists
+	violationMsgFNotAllowed = "The users with the %s role that are not allowed are: %s"
+	// Format string taking the subject, the matched forbiddenSubjects value, the role name, and the binding name
+	violationMsgFForbidden = "The %s matches the forbidden subject '%s' and is granted the %s role by the binding %s"
 	// The default IgnoreClusterRoleBindings regex when not specified in the policy.
 	defaultIgnoreCRBs = `^system:.+$`
 	ControllerName    = "iam-policy-controller"
+	// The CompliancyDetails key for the ClusterRoleBindings, other keys are namespaces
+	clusterWideKey = "cluster-wide"
+	// The role name reported when the ClusterRoles are selected by privilegedRules
+	privilegedRoleName = "privileged"
+	// The number of compliance history entries kept when complianceHistoryLimit is not set
+	defaultComplianceHistoryLimit = 10
+	// The prefix of the username that a ServiceAccount authenticates as
+	serviceAccountUserPrefix = "system:serviceaccount:"
+	// The ClusterRoleBinding annotation with the RFC 3339 time until which its subjects are approved
+	approvedUntilAnnotation = "policy.open-cluster-management.io/approved-until"
+	// Format string taking the subject, the role name, the binding name, and the expiration time
+	violationMsgFExpired = "The %s is granted the %s role by the binding %s whose approval expired at %s"
+	// Format string taking the role name
+	violationMsgFNotEnforced = "The subjects bound to the %s role are not removed since the policy doesn't " +
+		"set a maximum number of users"
+	// Format string taking the group names and the role name
+	violationMsgFUnresolved = "The users of the groups %s bound to the %s role can't be resolved and are " +
+		"counted as unlimited"
+	// The prefix of the user that an unresolved group is counted as with the CountAsOne mode
+	unresolvedGroupUserPrefix = "unresolved-group:"
+	// The frequency in seconds of the evaluation loop when the Evaluator frequency is not set
+	defaultFrequency = 10
+	// How long an evaluation of the policies may take before the evaluation loop is reported as unhealthy
+	evaluationStallTimeout = 10 * time.Minute
 )
 
 var (
 	log = ctrl.Log.WithName(ControllerName)
 	// blank assignment to verify that ReconcileIamPolicy implements reconcile.Reconciler
 	_ reconcile.Reconciler = &IamPolicyReconciler{}
-	// ClusterRoleBinding objects in OpenShift set the API group of a Group subject
-	// to rbac.authorization.k8s.io even though it should be user.openshift.io.
-	openShiftGroupGVR = schema.GroupVersionResource{
-		Group:    "user.openshift.io",
-		Version:  "v1",
-		Resource: "groups",
-	}
-	// availablePolicies is a cache of all available policies
-	availablePolicies common.SyncedPolicyMap
-	// PlcChan a channel used to pass policies ready for update
-	PlcChan chan *iampolicyv1.IamPolicy
+	// blank assignment to verify that the Evaluator runs its loop on the leader replica of the manager
+	_ manager.LeaderElectionRunnable = &Evaluator{}
+	// Formats the reason section of generated events
+	formatString = "policy: %s/%s"
+)
+
+// policyEvaluation is when a policy was last evaluated, and the generation that was evaluated
+type policyEvaluation struct {
+	time       time.Time
+	generation int64
+}
+
+// Evaluator evaluates the IamPolicies against the role bindings of a target cluster and reports their
+// compliance. It holds all the evaluation state, so several evaluators can run in the same process. The
+// IamPolicyReconciler adds the reconciled policies to it, and PeriodicallyExecIamPolicies evaluates them.
+// It may be created with NewEvaluator or as a struct literal, since its unexported state is initialized on
+// first use.
+type Evaluator struct {
+	// The client of the cluster with the policies, which updates their status and lists their exceptions
+	Client client.Client
+	// Records the compliance events of the policies, no events are recorded when nil
+	Recorder record.EventRecorder
 	// The Kubernetes client to use when evaluating/enforcing policies.
-	targetK8sClient *kubernetes.Interface
-	// The dynamic k8s  client to use when evaluating/enforcing policies.
-	targetK8sDynamicClient *dynamic.Interface
-	reconcilingAgent       *IamPolicyReconciler
+	TargetClient kubernetes.Interface
+	// The dynamic k8s client to use when evaluating/enforcing policies.
+	TargetDynamicClient dynamic.Interface
 	// EventOnParent specifies if we also want to send events to the parent policy. Available options
 	// are yes/no/ifpresent
 	EventOnParent string
-	// Formats the reason section of generated events
-	formatString = "policy: %s/%s"
-	// A way to allow exiting out of the periodic policy check loop
-	exitExecLoop string
-)
+	// The resolver of the users of Group subjects, nil to use the OpenShift groups of the target cluster
+	GroupResolver groups.Resolver
+	// How long a policy that can't be fully evaluated keeps its compliance state before it becomes unknown,
+	// which is immediately by default
+	UnknownComplianceGracePeriod time.Duration
+	// The frequency in seconds at which Start evaluates the policies without an evaluation interval, which
+	// defaults to 10 seconds
+	Frequency uint
 
-// Initialize  some controller variables
-func Initialize(
-	kClient *kubernetes.Interface,
-	kDynamicClient *dynamic.Interface,
-	eventParent string,
-) {
-	targetK8sClient = kClient
-	targetK8sDynamicClient = kDynamicClient
-	PlcChan = make(chan *iampolicyv1.IamPolicy, 100) // buffering up to 100 policies for update
+	// availablePolicies is a cache of all available policies
+	availablePolicies common.SyncedPolicyMap
+	// The last evaluation of each policy keyed like availablePolicies, only used by the periodic policy check loop
+	lastEvaluations map[string]policyEvaluation
+	// The keys of the policies due for evaluation in the current loop, nil when all policies are evaluated
+	duePolicies map[string]bool
+	// Wakes up the periodic policy check loop when the evaluation of a policy is requested
+	evaluateNow chan struct{}
+	// The keys of the policies to evaluate in the next loop regardless of their evaluation interval
+	evaluationRequests     map[string]bool
+	evaluationRequestsLock sync.Mutex
+	// Initializes the maps and the channel above on first use
+	initOnce sync.Once
+	// The listers of the informers on the target cluster, nil until the informers are synced
+	syncedListers atomic.Pointer[targetListers]
+	// The time by which the evaluation loop must make progress, nil when the loop is not running
+	loopDeadline atomic.Pointer[time.Time]
+}
+
+// NewEvaluator returns an Evaluator of the policies on the cluster of the client against the target cluster
+// of the target clients. The events are recorded with the recorder, which may be nil.
+func NewEvaluator(
+	client client.Client,
+	recorder record.EventRecorder,
+	targetClient kubernetes.Interface,
+	targetDynamicClient dynamic.Interface,
+	eventOnParent string,
+) *Evaluator {
+	return &Evaluator{
+		Client:              client,
+		Recorder:            recorder,
+		TargetClient:        targetClient,
+		TargetDynamicClient: targetDynamicClient,
+		EventOnParent:       strings.ToLower(eventOnParent),
+	}
+}
 
-	EventOnParent = strings.ToLower(eventParent)
+// initialize creates the unexported state of the evaluator that is not usable as a zero value, so that an
+// Evaluator created as a struct literal can be used.
+func (e *Evaluator) initialize() {
+	e.initOnce.Do(func() {
+		e.lastEvaluations = map[string]policyEvaluation{}
+		e.evaluateNow = make(chan struct{}, 1)
+		e.evaluationRequests = map[string]bool{}
+	})
 }
 
 // IamPolicyReconciler reconciles a IamPolicy object
 // Annotation for generating RBAC role for writing Events
 type IamPolicyReconciler struct {
 	client.Client
-	Scheme   *runtime.Scheme
-	Recorder record.EventRecorder
+	Scheme *runtime.Scheme
+	// Evaluator evaluates the reconciled policies
+	Evaluator *Evaluator
 }
 
 // +kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=iampolicies,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=iampolicies/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=iampolicies/finalizers,verbs=update
+// +kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=iampolicyexceptions,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get
-// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings,verbs=get;list
-// +kubebuilder:rbac:groups=user.openshift.io,resources=groups,verbs=get
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=get;list;watch
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=bind,resourceNames=cluster-admin
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=list
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=list
+// +kubebuilder:rbac:groups=user.openshift.io,resources=groups,verbs=get;list;watch
 
 // Reconcile reads that state of the cluster for a IamPolicy object and makes changes based on the state read
 // and what is in the IamPolicy.Spec
@@ -114,10 +205,6 @@ func (r *IamPolicyReconciler) Reconcile(tx context.Context, request ctrl.Request
 	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.Info("Reconciling IamPolicy")
 
-	if reconcilingAgent == nil {
-		reconcilingAgent = r
-	}
-
 	// Fetch the IamPolicy instance
 	instance := &iampolicyv1.IamPolicy{}
 
@@ -128,7 +215,7 @@ func (r *IamPolicyReconciler) Reconcile(tx context.Context, request ctrl.Request
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
 			reqLogger.Info("Policy could not be found, removing it")
-			handleRemovingPolicy(request.NamespacedName.Name, request.NamespacedName.Namespace)
+			r.Evaluator.handleRemovingPolicy(request.NamespacedName.Name, request.NamespacedName.Namespace)
 
 			return reconcile.Result{}, nil
 		}
@@ -141,7 +228,8 @@ func (r *IamPolicyReconciler) Reconcile(tx context.Context, request ctrl.Request
 		instance.Status.CompliancyDetails = nil // reset CompliancyDetails
 
 		reqLogger.Info("Iam policy was found, adding it...")
-		handleAddingPolicy(instance)
+		r.Evaluator.handleAddingPolicy(instance)
+		r.Evaluator.requestEvaluation(fmt.Sprintf("%s.%s", instance.Namespace, instance.Name))
 	}
 
 	reqLogger.Info("Reconcile complete.")
@@ -149,127 +237,316 @@ func (r *IamPolicyReconciler) Reconcile(tx context.Context, request ctrl.Request
 	return reconcile.Result{}, nil
 }
 
-// SetupWithManager sets up the controller with the Manager.
+// SetupWithManager sets up the controller with the Manager. The policies are also reconciled when the
+// ClusterRoleBindings, ClusterRoles, or groups they evaluate change on the target cluster.
 func (r *IamPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&iampolicyv1.IamPolicy{}).
-		Complete(r)
+	blder := ctrl.NewControllerManagedBy(mgr).
+		// The status updates made after each evaluation must not trigger another evaluation
+		For(&iampolicyv1.IamPolicy{}, builder.WithPredicates(predicate.Or(
+			predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{},
+			predicate.AnnotationChangedPredicate{},
+		)))
+
+	if err := r.Evaluator.watchTargetCluster(mgr, blder); err != nil {
+		return err
+	}
+
+	return blder.Complete(r)
+}
+
+// Start runs the evaluation loop with the frequency of the evaluator until the context is canceled. The
+// Evaluator is a manager.Runnable so that the manager only evaluates the policies and writes their status on
+// the leader replica.
+func (e *Evaluator) Start(ctx context.Context) error {
+	freq := e.Frequency
+	if freq == 0 {
+		freq = defaultFrequency
+	}
+
+	log.Info("Starting the evaluation loop", "frequency", freq)
+
+	e.PeriodicallyExecIamPolicies(ctx, freq)
+
+	return nil
+}
+
+// NeedLeaderElection returns true since only the leader replica may write the status of the policies.
+func (e *Evaluator) NeedLeaderElection() bool {
+	return true
+}
+
+// HealthCheck is a healthz.Checker which fails when the evaluation loop hasn't made progress in time, such as
+// when an evaluation is stuck. It passes when the loop isn't running, like on the replicas which are not the
+// leader.
+func (e *Evaluator) HealthCheck(_ *http.Request) error {
+	deadline := e.loopDeadline.Load()
+	if deadline != nil && time.Now().After(*deadline) {
+		return fmt.Errorf("the evaluation loop has not made progress since %s", deadline.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// setLoopDeadline sets the time by which the evaluation loop must make progress again, which is after the
+// next evaluation and the sleep until the following one.
+func (e *Evaluator) setLoopDeadline(defaultInterval time.Duration) {
+	deadline := time.Now().Add(evaluationStallTimeout + defaultInterval)
+	e.loopDeadline.Store(&deadline)
 }
 
-// PeriodicallyExecIamPolicies always check status - let this be the only function in the controller
-func PeriodicallyExecIamPolicies(freq uint) {
+// PeriodicallyExecIamPolicies always check status - let this be the only function in the controller. Each
+// policy is evaluated once its evaluation interval elapsed, which defaults to the frequency in seconds. It
+// returns when the context is canceled, after the evaluation in progress.
+func (e *Evaluator) PeriodicallyExecIamPolicies(ctx context.Context, freq uint) {
 	log.V(3).Info("Entered PeriodicallyExecIamPolicies")
 	var plcToUpdateMap map[string]*iampolicyv1.IamPolicy
 
+	defaultInterval := time.Duration(freq) * time.Second
+
+	e.initialize()
+
+	defer e.loopDeadline.Store(nil)
+
 	for {
 		start := time.Now()
 
-		printMap(availablePolicies.PolicyMap)
+		e.setLoopDeadline(defaultInterval)
+
+		e.availablePolicies.Mx.RLock()
+		printMap(e.availablePolicies.PolicyMap)
+		e.availablePolicies.Mx.RUnlock()
+
+		e.duePolicies, _ = e.getDuePolicies(start, defaultInterval)
+
+		for key := range e.takeEvaluationRequests() {
+			e.duePolicies[key] = true
+		}
 
 		plcToUpdateMap = make(map[string]*iampolicyv1.IamPolicy)
 
-		// currently no support for perNamespace rolebindings
-		update, err := checkUnNamespacedPolicies(plcToUpdateMap)
-		if err != nil {
-			log.Error(err, "Error checking un-namespaced policies")
+		update, unNamespacedErr := e.checkUnNamespacedPolicies(plcToUpdateMap)
+		if unNamespacedErr != nil {
+			log.Error(unNamespacedErr, "Error checking un-namespaced policies")
 		}
 
-		if update {
+		namespacedUpdate, namespacedErr := e.checkNamespacedPolicies(plcToUpdateMap)
+		if namespacedErr != nil {
+			log.Error(namespacedErr, "Error checking namespaced policies")
+		}
+
+		summaryUpdate := e.summarizePoliciesStatus(plcToUpdateMap, unNamespacedErr, namespacedErr)
+
+		if update || namespacedUpdate || summaryUpdate {
 			// update status of all policies that changed:
-			faultyPlc, err := updatePolicyStatus(plcToUpdateMap)
-			if err != nil {
-				log.Error(err, "Unable to update policy status",
-					"Name", faultyPlc.Name, "Namespace", faultyPlc.Namespace)
+			if err := e.updatePolicyStatus(plcToUpdateMap); err != nil {
+				log.Error(err, "Unable to update policy status")
 			}
 		}
 
+		e.recordEvaluations(e.duePolicies, start)
+		e.duePolicies = nil
+
+		e.setLoopDeadline(defaultInterval)
+
 		// check if continue
-		if exitExecLoop == "true" {
+		if ctx.Err() != nil {
 			log.V(3).Info("Exiting PeriodicallyExecIamPolicies")
 
 			return
 		}
-		// making sure that if processing is > freq we don't sleep
-		// if freq > processing we sleep for the remaining duration
-		elapsed := time.Since(start) / 1000000000 // convert to seconds
-		if float64(freq) > float64(elapsed) {
-			remainingSleep := float64(freq) - float64(elapsed)
-			time.Sleep(time.Duration(remainingSleep) * time.Second)
+
+		// Sleep until the next policy is due, unless the evaluation of a policy is requested in the meantime
+		_, nextEvaluation := e.getDuePolicies(time.Now(), defaultInterval)
+		timer := time.NewTimer(time.Until(nextEvaluation))
+
+		select {
+		case <-timer.C:
+		case <-e.evaluateNow:
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+}
+
+// getEvaluationInterval returns the minimum time between evaluations of the policy based on its compliance
+// state, or the default interval when it is not set. ErrIsNever is returned when the policy must not be
+// evaluated again. A policy whose last evaluation failed is evaluated again after the default interval.
+func getEvaluationInterval(policy *iampolicyv1.IamPolicy, defaultInterval time.Duration) (time.Duration, error) {
+	var interval time.Duration
+	var err error
+
+	if meta.IsStatusConditionFalse(policy.Status.Conditions, iampolicyv1.ConditionEvaluationSucceeded) {
+		return defaultInterval, nil
+	}
+
+	switch policy.Status.ComplianceState {
+	case iampolicyv1.Compliant:
+		interval, err = policy.Spec.EvaluationInterval.GetCompliantInterval()
+	case iampolicyv1.NonCompliant:
+		interval, err = policy.Spec.EvaluationInterval.GetNonCompliantInterval()
+	case iampolicyv1.UnknownCompliancy:
+	}
+
+	if err != nil {
+		if stderrors.Is(err, iampolicyv1.ErrIsNever) {
+			return 0, err
+		}
+
+		log.Error(err, "Using the default evaluation interval", "Name", policy.Name, "Namespace", policy.Namespace)
+	}
+
+	if interval <= 0 {
+		return defaultInterval, nil
+	}
+
+	return interval, nil
+}
+
+// getDuePolicies returns the keys of the policies to evaluate at the given time, which are the policies that
+// were not evaluated yet, whose spec changed since their last evaluation, or whose evaluation interval
+// elapsed. It also returns the time at which the next policy is due, which is at most the default interval
+// after the given time.
+func (e *Evaluator) getDuePolicies(now time.Time, defaultInterval time.Duration) (due map[string]bool, next time.Time) {
+	due = map[string]bool{}
+	next = now.Add(defaultInterval)
+
+	e.availablePolicies.Mx.RLock()
+	defer e.availablePolicies.Mx.RUnlock()
+
+	for key, policy := range e.availablePolicies.PolicyMap {
+		last, evaluated := e.lastEvaluations[key]
+		if !evaluated || last.generation != policy.Generation {
+			due[key] = true
+
+			continue
+		}
+
+		interval, err := getEvaluationInterval(policy, defaultInterval)
+		if err != nil {
+			log.V(2).Info("Skipping the evaluation of the policy since its evaluation interval is never",
+				"Name", policy.Name, "Namespace", policy.Namespace, "ComplianceState", policy.Status.ComplianceState)
+
+			continue
+		}
+
+		nextPolicyEvaluation := last.time.Add(interval)
+		if !nextPolicyEvaluation.After(now) {
+			due[key] = true
+
+			continue
+		}
+
+		if nextPolicyEvaluation.Before(next) {
+			next = nextPolicyEvaluation
+		}
+	}
+
+	return due, next
+}
+
+// recordEvaluations records the evaluation time and generation of the evaluated policies, and forgets the
+// policies that are no longer available.
+func (e *Evaluator) recordEvaluations(evaluated map[string]bool, evaluationTime time.Time) {
+	e.initialize()
+
+	e.availablePolicies.Mx.RLock()
+	defer e.availablePolicies.Mx.RUnlock()
+
+	for key := range e.lastEvaluations {
+		if _, ok := e.availablePolicies.PolicyMap[key]; !ok {
+			delete(e.lastEvaluations, key)
+		}
+	}
+
+	for key := range evaluated {
+		if policy, ok := e.availablePolicies.PolicyMap[key]; ok {
+			e.lastEvaluations[key] = policyEvaluation{time: evaluationTime, generation: policy.Generation}
 		}
 	}
 }
 
-func checkUnNamespacedPolicies(
+func (e *Evaluator) checkUnNamespacedPolicies(
 	plcToUpdateMap map[string]*iampolicyv1.IamPolicy,
 ) (bool, error) {
-	plcMap := convertMaptoPolicyNameKey()
+	plcMap := e.convertMaptoPolicyNameKey()
 
 	// group the policies with cluster users and the ones with groups
 	// take the plc with min users and groups and make it your baseline
 
-	ClusteRoleBindingList, err := (*targetK8sClient).RbacV1().ClusterRoleBindings().List(
-		context.TODO(),
-		metav1.ListOptions{})
+	ClusteRoleBindingList, err := e.listClusterRoleBindings()
 	if err != nil {
 		log.Error(err, "Error listing ClusterRoleBindings")
 
 		return false, err
 	}
 
-	update := false
+	clusterRoles, err := e.listClusterRoles()
+	if err != nil {
+		log.Error(err, "Error listing ClusterRoles")
 
-	for _, policy := range plcMap {
-		var userViolationCount int
+		return false, err
+	}
 
-		clusterRoleRef := "cluster-admin"
-		if policy.Spec.ClusterRole != "" {
-			clusterRoleRef = policy.Spec.ClusterRole
-		}
+	// Without the exceptions, the excepted subjects would be counted and removed when enforcing
+	exceptions, err := e.listPolicyExceptions()
+	if err != nil {
+		log.Error(err, "Error listing IamPolicyExceptions")
 
-		clusterLevelUsers, err := checkAllClusterLevel(
-			ClusteRoleBindingList,
-			clusterRoleRef,
-			policy.Spec.IgnoreClusterRoleBindings,
-		)
+		return false, err
+	}
 
-		queryErrEncountered := false
+	update := false
 
-		if err != nil {
-			queryErrEncountered = true
+	for key, policy := range plcMap {
+		expectedKeys := map[string]bool{}
+		removed := []iampolicyv1.RemovedSubject{}
 
-			log.Info("Error listing users bound to ClusterRole.", "Name", policy.Name, "ClusterRole", clusterRoleRef)
+		bindingList, err := filterClusterRoleBindings(ClusteRoleBindingList, &policy.Spec)
+		if err != nil {
+			log.Error(err, "Error selecting ClusterRoleBindings", "Name", policy.Name)
 		}
 
-		log.Info(fmt.Sprintf("Found %d users bound to ClusterRole.", clusterLevelUsers),
-			"Name", policy.Name, "ClusterRole", clusterRoleRef)
-
-		if policy.Spec.MaxClusterRoleBindingUsers < clusterLevelUsers && policy.Spec.MaxClusterRoleBindingUsers >= 0 {
-			userViolationCount = clusterLevelUsers - policy.Spec.MaxClusterRoleBindingUsers
-		}
+		// Every role is evaluated from the same ClusterRoleBinding list
+		for _, role := range getRoleLimits(policy, clusterRoles) {
+			expectedKeys[getDetailsKey(policy, clusterWideKey, role.name)] = true
 
-		// Handle the case when there was an error getting the whole user list.
-		if queryErrEncountered {
-			// Even if there was an error getting the whole user list, as long as we know there is
-			// a violation, the policy should be updated to non-compliant unless it is already
-			// non-compliant. If it's already non-compliant, we don't want to only have the number
-			// of users change.
-			if userViolationCount > 0 {
-				if policy.Status.ComplianceState == iampolicyv1.NonCompliant {
-					continue
+			if err != nil {
+				if setRoleEvaluation(policy, newRoleEvaluationError(clusterWideKey, role, err)) {
+					plcToUpdateMap[policy.Name] = policy
+					update = true
 				}
-			} else if policy.Status.ComplianceState != iampolicyv1.Compliant {
-				log.Info("Not updating status to compliant due to error listing users.", "Name", policy.Name)
 
 				continue
 			}
+
+			changed, roleRemoved := e.checkClusterRole(policy, role, bindingList, exceptions[key])
+			if changed {
+				plcToUpdateMap[policy.Name] = policy
+				update = true
+			}
+
+			removed = append(removed, roleRemoved...)
+		}
+
+		if len(removed) > 0 {
+			// The filtered list holds copies, so the removals must be reflected in the full list for the
+			// other policies
+			if bindingList != ClusteRoleBindingList {
+				syncClusterRoleBindingSubjects(ClusteRoleBindingList, bindingList)
+			}
+
+			policy.Status.RemovedSubjects = removed
+			plcToUpdateMap[policy.Name] = policy
+			update = true
 		}
 
-		if addViolationCount(policy, clusterRoleRef, userViolationCount, "cluster-wide") {
+		if removeStaleDetails(policy, expectedKeys, true) {
 			plcToUpdateMap[policy.Name] = policy
 			update = true
 		}
 
-		if checkComplianceBasedOnDetails(policy, clusterRoleRef) {
+		if checkComplianceBasedOnEvaluations(policy) {
 			plcToUpdateMap[policy.Name] = policy
 			update = true
 		}
@@ -278,231 +555,1867 @@ func checkUnNamespacedPolicies(
 	return update, nil
 }
 
-// getGroupMembership queries for the membership of an OpenShift group. If the group is not found
-// or is malformed, and empty string slice is returned. If the query itself failed, an error is
-// returned.
-func getGroupMembership(group string) ([]string, error) {
-	openShiftUserGroup, err := (*targetK8sDynamicClient).Resource(openShiftGroupGVR).Get(
-		context.TODO(),
-		group,
-		metav1.GetOptions{})
+// filterClusterRoleBindings returns the ClusterRoleBindings matching the labelSelector and
+// labelSelectorExpressions of the policy. The list is returned as is when neither is set.
+func filterClusterRoleBindings(
+	clusterRoleBindingList *v1.ClusterRoleBindingList, spec *iampolicyv1.IamPolicySpec,
+) (*v1.ClusterRoleBindingList, error) {
+	if len(spec.LabelSelector) == 0 && len(spec.LabelSelectorExpressions) == 0 {
+		return clusterRoleBindingList, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
+		MatchLabels:      spec.LabelSelector,
+		MatchExpressions: spec.LabelSelectorExpressions,
+	})
 	if err != nil {
-		if errors.IsNotFound(err) {
-			log.Info(fmt.Sprintf("The group %s was not found.", group))
+		return nil, fmt.Errorf("the label selector is invalid: %w", err)
+	}
 
-			return []string{}, nil
-		}
+	filtered := &v1.ClusterRoleBindingList{Items: []v1.ClusterRoleBinding{}}
 
-		return nil, fmt.Errorf("failed to get OpenShift group %s: %w", group, err)
+	for _, clusterRoleBinding := range clusterRoleBindingList.Items {
+		if selector.Matches(labels.Set(clusterRoleBinding.Labels)) {
+			filtered.Items = append(filtered.Items, clusterRoleBinding)
+		}
 	}
 
-	users, found, err := unstructured.NestedStringSlice(openShiftUserGroup.Object, "users")
-	if err != nil || !found {
-		log.Info(fmt.Sprintf("Could not retrieve users from group '%s'.", group))
+	return filtered, nil
+}
+
+// syncClusterRoleBindingSubjects sets the subjects of the ClusterRoleBindings in the list to the subjects
+// of the ClusterRoleBindings with the same name in the filtered list.
+func syncClusterRoleBindingSubjects(
+	clusterRoleBindingList *v1.ClusterRoleBindingList, filtered *v1.ClusterRoleBindingList,
+) {
+	subjects := make(map[string][]v1.Subject, len(filtered.Items))
 
-		// returning nil here is intentional
-		//nolint:nilerr
-		return []string{}, nil
+	for _, clusterRoleBinding := range filtered.Items {
+		subjects[clusterRoleBinding.Name] = clusterRoleBinding.Subjects
 	}
 
-	return users, nil
+	for i := range clusterRoleBindingList.Items {
+		if filteredSubjects, ok := subjects[clusterRoleBindingList.Items[i].Name]; ok {
+			clusterRoleBindingList.Items[i].Subjects = filteredSubjects
+		}
+	}
 }
 
-func checkAllClusterLevel(
+// checkClusterRole evaluates the ClusterRoleBindings referencing the role and sets the results in the
+// policy status. When the policy is enforced, the excess subjects are removed before the results are set. It
+// returns whether the status changed and the removed subjects.
+func (e *Evaluator) checkClusterRole(
+	policy *iampolicyv1.IamPolicy,
+	role roleLimit,
 	clusterRoleBindingList *v1.ClusterRoleBindingList,
-	clusterroleref string,
-	ignoreCRBs []iampolicyv1.NonEmptyString,
-) (userV int, err error) {
-	if len(ignoreCRBs) == 0 {
-		ignoreCRBs = []iampolicyv1.NonEmptyString{defaultIgnoreCRBs}
+	exceptions []iampolicyv1.IamPolicyException,
+) (changed bool, removed []iampolicyv1.RemovedSubject) {
+	grants, clusterLevelUsers, ignoredSubjects, err := e.checkAllClusterLevel(
+		clusterRoleBindingList,
+		role.clusterRoleRefs,
+		policy.Spec.IgnoreClusterRoleBindings,
+		policy.Spec.IgnoreSubjects,
+		policy.Spec.IncludeServiceAccounts,
+		exceptions,
+		getMaxApproval(policy),
+		policy.Spec.UnresolvableGroups,
+	)
+	if err != nil {
+		log.Error(err, "Error listing users bound to ClusterRole", "Name", policy.Name, "ClusterRole", role.name)
+
+		return setRoleEvaluation(policy, newRoleEvaluationError(clusterWideKey, role, err)), nil
 	}
 
-	compiledIgnoreCRBs := make([]*regexp.Regexp, 0, len(ignoreCRBs))
+	log.Info(fmt.Sprintf("Found %d users bound to ClusterRole.", clusterLevelUsers),
+		"Name", policy.Name, "ClusterRole", role.name)
+
+	evaluation := newRoleEvaluation(clusterWideKey, role, clusterLevelUsers)
+	evaluation.IgnoredSubjects = ignoredSubjects
+	evaluation.ApprovedUsers = getApprovedUsers(grants)
+	evaluation.ApprovedSubjects = len(evaluation.ApprovedUsers)
+	evaluation.AppliedExceptions = getAppliedExceptions(grants)
+	evaluation.UnresolvedGroups = getUnresolvedGroups(grants)
+	evaluation.EvaluationErrors = getResolveErrors(grants)
+
+	enforce := (evaluation.Excess > 0 || hasExpiredApproval(grants)) && isEnforce(policy)
+
+	// An omitted limit is 0, and enforcing it would remove every subject of the ClusterRoleBindings
+	notEnforced := enforce && role.maxUsers < 1
+	if notEnforced {
+		log.Info("Not enforcing the policy since it doesn't set a maximum number of users", "Name", policy.Name,
+			"ClusterRole", role.name)
+
+		enforce = false
+	}
+
+	if enforce {
+		removed, err = e.removeExcessSubjects(grants, role.maxUsers)
+		e.recordRemovedSubjects(policy, role.name, removed)
 
-	for _, regex := range ignoreCRBs {
-		regex, err := regexp.Compile(string(regex))
 		if err != nil {
-			err = fmt.Errorf("ignoreClusterRoleBindings value '%s' is not a valid regular expression: %w", regex, err)
+			log.Error(err, "Error removing subjects bound to ClusterRole", "Name", policy.Name,
+				"ClusterRole", role.name)
+		}
 
-			return 0, err
+		// Evaluate the ClusterRoleBindings again since the removed subjects are no longer in them
+		if len(removed) > 0 {
+			grants, clusterLevelUsers, ignoredSubjects, err = e.checkAllClusterLevel(
+				clusterRoleBindingList,
+				role.clusterRoleRefs,
+				policy.Spec.IgnoreClusterRoleBindings,
+				policy.Spec.IgnoreSubjects,
+				policy.Spec.IncludeServiceAccounts,
+				exceptions,
+				getMaxApproval(policy),
+				policy.Spec.UnresolvableGroups,
+			)
+			if err != nil {
+				return setRoleEvaluation(policy, newRoleEvaluationError(clusterWideKey, role, err)), removed
+			}
+
+			evaluation = newRoleEvaluation(clusterWideKey, role, clusterLevelUsers)
+			evaluation.IgnoredSubjects = ignoredSubjects
+			evaluation.ApprovedUsers = getApprovedUsers(grants)
+			evaluation.ApprovedSubjects = len(evaluation.ApprovedUsers)
+			evaluation.AppliedExceptions = getAppliedExceptions(grants)
+			evaluation.UnresolvedGroups = getUnresolvedGroups(grants)
+			evaluation.EvaluationErrors = getResolveErrors(grants)
 		}
+	}
 
-		compiledIgnoreCRBs = append(compiledIgnoreCRBs, regex)
+	setEvaluationViolations(&evaluation, grants, &policy.Spec)
+
+	if notEnforced {
+		evaluation.Violations = append(evaluation.Violations, fmt.Sprintf(violationMsgFNotEnforced, role.name))
 	}
 
-	usersMap := make(map[string]bool)
+	return setRoleEvaluation(policy, evaluation), removed
+}
 
-	for _, clusterRoleBinding := range clusterRoleBindingList.Items {
-		ignore := false
+// checkNamespacedPolicies evaluates the RoleBindings in the namespaces selected by the namespaceSelector
+// of each policy. Each namespace with users bound to a ClusterRole gets its own entry in the policy's
+// CompliancyDetails.
+func (e *Evaluator) checkNamespacedPolicies(plcToUpdateMap map[string]*iampolicyv1.IamPolicy) (bool, error) {
+	plcMap := e.convertMaptoPolicyNameKey()
+	update := false
 
-		for _, regex := range compiledIgnoreCRBs {
-			if regex.MatchString(clusterRoleBinding.Name) {
-				log.Info(fmt.Sprintf("ignoreClusterRoleBinding entry '%s' matched '%s'. Skipping.",
-					regex, clusterRoleBinding.Name))
+	var namespaces []string
 
-				ignore = true
+	// RoleBindings keyed by namespace, only listed if a policy selects namespaces
+	var roleBindings map[string][]v1.RoleBinding
 
-				break
+	// ClusterRoles, only listed if a policy selects namespaces
+	var clusterRoles []v1.ClusterRole
+
+	// IamPolicyExceptions keyed like the policies, only listed if a policy selects namespaces
+	var exceptions map[string][]iampolicyv1.IamPolicyException
+
+	for key, policy := range plcMap {
+		if len(policy.Spec.NamespaceSelector.Include) == 0 {
+			if removeStaleDetails(policy, nil, false) {
+				checkComplianceBasedOnEvaluations(policy)
+				plcToUpdateMap[policy.Name] = policy
+				update = true
+			}
+
+			continue
+		}
+
+		if roleBindings == nil {
+			var err error
+
+			namespaces, roleBindings, err = e.listNamespacedRoleBindings()
+			if err != nil {
+				log.Error(err, "Error listing RoleBindings")
+
+				return update, err
+			}
+
+			clusterRoles, err = e.listClusterRoles()
+			if err != nil {
+				log.Error(err, "Error listing ClusterRoles")
+
+				return update, err
+			}
+
+			exceptions, err = e.listPolicyExceptions()
+			if err != nil {
+				log.Error(err, "Error listing IamPolicyExceptions")
+
+				return update, err
 			}
 		}
 
-		if ignore {
+		selectedNamespaces, err := getSelectedNamespaces(namespaces, policy.Spec.NamespaceSelector)
+		if err != nil {
+			log.Error(err, "Error selecting namespaces", "Name", policy.Name)
+
 			continue
 		}
 
-		// Only consider role bindings with matching referenced cluster role
-		roleRef := clusterRoleBinding.RoleRef
-		if roleRef.Kind == "ClusterRole" && roleRef.Name == clusterroleref {
-			for _, subject := range clusterRoleBinding.Subjects {
-				if subject.Kind == "User" {
-					usersMap[subject.Name] = true
-				} else if subject.Kind == "Group" {
-					users, err := getGroupMembership(subject.Name)
-					if err != nil {
-						log.Error(err, "Error retrieving users in group (policy compliance will be unknown)",
-							"ClusterRoleBinding", clusterRoleBinding.Name, "ClusterRole", clusterroleref,
-							"Group", subject.Name)
-
-						continue
+		evaluated := map[string]bool{}
+
+		var queryErr error
+
+		roles := getRoleLimits(policy, clusterRoles)
+
+	namespaceLoop:
+		for _, namespace := range selectedNamespaces {
+			for _, role := range roles {
+				var grants []roleGrant
+				var namespaceUsers, ignoredSubjects int
+
+				grants, namespaceUsers, ignoredSubjects, queryErr = e.checkNamespaceLevel(
+					roleBindings[namespace],
+					role.clusterRoleRefs,
+					policy.Spec.IgnoreClusterRoleBindings,
+					policy.Spec.IgnoreSubjects,
+					policy.Spec.IncludeServiceAccounts,
+					exceptions[key],
+					policy.Spec.UnresolvableGroups,
+				)
+				if queryErr != nil {
+					log.Info("Error listing users bound to ClusterRole in namespace.", "Name", policy.Name,
+						"ClusterRole", role.name, "Namespace", namespace)
+
+					// The same settings fail in every namespace, so a single evaluation error is reported
+					evaluated[getDetailsKey(policy, namespace, role.name)] = true
+
+					if setRoleEvaluation(policy, newRoleEvaluationError(namespace, role, queryErr)) {
+						plcToUpdateMap[policy.Name] = policy
+						update = true
 					}
 
-					for _, user := range users {
-						usersMap[user] = true
-					}
+					break namespaceLoop
+				}
+
+				appliedExceptions := getAppliedExceptions(grants)
+				unresolvedGroups := getUnresolvedGroups(grants)
+
+				if namespaceUsers == 0 && len(appliedExceptions) == 0 && len(unresolvedGroups) == 0 {
+					continue
+				}
+
+				evaluated[getDetailsKey(policy, namespace, role.name)] = true
+
+				evaluation := newRoleEvaluation(namespace, role, namespaceUsers)
+				evaluation.IgnoredSubjects = ignoredSubjects
+				evaluation.ApprovedUsers = getApprovedUsers(grants)
+				evaluation.ApprovedSubjects = len(evaluation.ApprovedUsers)
+				evaluation.AppliedExceptions = appliedExceptions
+				evaluation.UnresolvedGroups = unresolvedGroups
+				evaluation.EvaluationErrors = getResolveErrors(grants)
+				setEvaluationViolations(&evaluation, grants, &policy.Spec)
+
+				if setRoleEvaluation(policy, evaluation) {
+					plcToUpdateMap[policy.Name] = policy
+					update = true
 				}
 			}
 		}
-	}
 
-	return len(usersMap), err
-}
+		if removeStaleDetails(policy, evaluated, false) {
+			plcToUpdateMap[policy.Name] = policy
+			update = true
+		}
 
-func convertMaptoPolicyNameKey() map[string]*iampolicyv1.IamPolicy {
-	plcMap := make(map[string]*iampolicyv1.IamPolicy)
-	for _, policy := range availablePolicies.PolicyMap {
-		plcMap[fmt.Sprintf("%s.%s", policy.Namespace, policy.Name)] = policy
+		if checkComplianceBasedOnEvaluations(policy) {
+			plcToUpdateMap[policy.Name] = policy
+			update = true
+		}
 	}
 
-	return plcMap
+	return update, nil
 }
 
-func addViolationCount(plc *iampolicyv1.IamPolicy, roleName string, userCount int, namespace string) (changed bool) {
-	msg := fmt.Sprintf(violationMsgF, roleName, fmt.Sprint(userCount))
-
-	if plc.Status.CompliancyDetails == nil {
-		plc.Status.CompliancyDetails = make(map[string]iampolicyv1.CompliancyDetail)
+// listNamespacedRoleBindings returns the names of all namespaces and the RoleBindings keyed by namespace.
+func (e *Evaluator) listNamespacedRoleBindings() ([]string, map[string][]v1.RoleBinding, error) {
+	namespaceList, err := e.TargetClient.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list the namespaces: %w", err)
 	}
 
-	if _, ok := plc.Status.CompliancyDetails[plc.Name]; !ok {
-		plc.Status.CompliancyDetails[plc.Name] = make(map[string][]string)
-	}
+	namespaces := make([]string, 0, len(namespaceList.Items))
 
-	if plc.Status.CompliancyDetails[plc.Name][namespace] == nil {
-		plc.Status.CompliancyDetails[plc.Name][namespace] = []string{}
+	for _, namespace := range namespaceList.Items {
+		namespaces = append(namespaces, namespace.Name)
 	}
 
-	if len(plc.Status.CompliancyDetails[plc.Name][namespace]) == 0 {
-		plc.Status.CompliancyDetails[plc.Name][namespace] = []string{msg}
-
-		return true
+	roleBindingList, err := e.TargetClient.RbacV1().RoleBindings("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list the RoleBindings: %w", err)
 	}
 
-	oldUserCount, err := extractUserCount(plc.Status.CompliancyDetails[plc.Name][namespace][0], roleName)
+	roleBindings := map[string][]v1.RoleBinding{}
 
-	if err == nil && oldUserCount == userCount {
-		return false
+	for _, roleBinding := range roleBindingList.Items {
+		roleBindings[roleBinding.Namespace] = append(roleBindings[roleBinding.Namespace], roleBinding)
 	}
 
-	plc.Status.CompliancyDetails[plc.Name][namespace][0] = msg
-
-	return true
+	return namespaces, roleBindings, nil
 }
 
-// checkComplianceBasedOnDetails ensures the policy's overall ComplianceState
-// matches what is described in the policy's CompliancyDetails, and returns true
-// if the ComplianceState changed, ie, if the policy status should be updated.
-func checkComplianceBasedOnDetails(plc *iampolicyv1.IamPolicy, roleName string) bool {
-	previousComplianceState := plc.Status.ComplianceState
+// removeStaleDetails removes the CompliancyDetails keys that are not in keep, and returns true if any
+// were removed. Only the cluster-wide keys are considered if clusterWide is set, otherwise only the
+// namespace keys are considered.
+func removeStaleDetails(plc *iampolicyv1.IamPolicy, keep map[string]bool, clusterWide bool) bool {
+	changed := false
 
-	plc.Status.ComplianceState = iampolicyv1.Compliant
-	if plc.Status.CompliancyDetails == nil {
-		return previousComplianceState != plc.Status.ComplianceState
-	}
+	isStale := func(evaluation iampolicyv1.RoleEvaluation) bool {
+		key := getDetailsKey(plc, evaluation.Scope, evaluation.Role)
+		stale := (evaluation.Scope == clusterWideKey) == clusterWide && !keep[key]
+		changed = changed || stale
 
-	if _, ok := plc.Status.CompliancyDetails[plc.Name]; !ok {
-		return previousComplianceState != plc.Status.ComplianceState
+		return stale
 	}
 
-	if len(plc.Status.CompliancyDetails[plc.Name]) == 0 {
-		return previousComplianceState != plc.Status.ComplianceState
-	}
+	plc.Status.Evaluations = slices.DeleteFunc(plc.Status.Evaluations, isStale)
 
-	for namespace, msgList := range plc.Status.CompliancyDetails[plc.Name] {
-		if len(msgList) == 0 {
-			return previousComplianceState != plc.Status.ComplianceState
-		}
+	for key := range plc.Status.CompliancyDetails[plc.Name] {
+		if isClusterWideKey(key) == clusterWide && !keep[key] {
+			delete(plc.Status.CompliancyDetails[plc.Name], key)
 
-		userCount, err := extractUserCount(plc.Status.CompliancyDetails[plc.Name][namespace][0], roleName)
-		if err == nil && userCount != 0 {
-			plc.Status.ComplianceState = iampolicyv1.NonCompliant
+			changed = true
 		}
 	}
 
-	return previousComplianceState != plc.Status.ComplianceState
+	return changed
 }
 
-func updatePolicyStatus(policies map[string]*iampolicyv1.IamPolicy) (*iampolicyv1.IamPolicy, error) {
-	log.Info("Updating status for IAM Policies")
+func getClusterRoleRef(plc *iampolicyv1.IamPolicy) string {
+	if plc.Spec.ClusterRole != "" {
+		return plc.Spec.ClusterRole
+	}
 
-	for _, instance := range policies { // policies is a map where: key = plc.Name, value = pointer to plc
-		err := reconcilingAgent.Status().Update(context.TODO(), instance)
-		if err != nil {
-			return instance, err
-		}
+	return "cluster-admin"
+}
 
-		if EventOnParent != "no" {
-			createParentPolicyEvent(instance)
-		}
+// roleLimit is a role evaluated by a policy and the maximum number of users that can be bound to it.
+type roleLimit struct {
+	// The role name reported in the CompliancyDetails
+	name string
+	// The ClusterRoles that the bindings must reference to grant the role
+	clusterRoleRefs []string
+	maxUsers        int
+}
 
-		// Can we make this eventing enabled by a flag
-		if reconcilingAgent.Recorder != nil {
-			if instance.Status.ComplianceState == iampolicyv1.NonCompliant {
-				reconcilingAgent.Recorder.Event(instance, corev1.EventTypeWarning,
-					fmt.Sprintf(formatString, instance.Namespace, instance.Name),
-					convertPolicyStatusToString(instance))
-			} else {
-				reconcilingAgent.Recorder.Event(instance, corev1.EventTypeNormal,
-					fmt.Sprintf(formatString, instance.Namespace, instance.Name),
-					convertPolicyStatusToString(instance))
-			}
-		}
+// getRoleLimits returns the roles evaluated by the policy. When privilegedRules is set, this is a single
+// role granted by every ClusterRole matching the rules, limited by maxClusterRoleBindingUsers. Otherwise,
+// it is the clusterRoles entries when set, or the clusterRole limited by maxClusterRoleBindingUsers, and
+// each role is also granted by the ClusterRoles related to it through aggregation.
+func getRoleLimits(plc *iampolicyv1.IamPolicy, clusterRoles []v1.ClusterRole) []roleLimit {
+	if len(plc.Spec.PrivilegedRules) != 0 {
+		privilegedRoles := getPrivilegedClusterRoles(clusterRoles, plc.Spec.PrivilegedRules)
 
-		log.Info("Status update complete", "IAMPolicy", instance.Name)
+		return []roleLimit{{privilegedRoleName, privilegedRoles, plc.Spec.MaxClusterRoleBindingUsers}}
 	}
 
-	// return nil here is intentional
-	return nil, nil
-}
-
-func extractUserCount(msg, roleName string) (int, error) {
-	regexStr := fmt.Sprintf(violationMsgFUserCountRegex, regexp.QuoteMeta(roleName))
+	if len(plc.Spec.ClusterRoles) == 0 {
+		clusterRoleRef := getClusterRoleRef(plc)
 
-	regularExpression, err := regexp.Compile(regexStr)
-	if err != nil {
-		return 0, err
+		return []roleLimit{{
+			clusterRoleRef,
+			getAggregatedClusterRoles(clusterRoles, clusterRoleRef),
+			plc.Spec.MaxClusterRoleBindingUsers,
+		}}
 	}
 
-	regexGroups := regularExpression.FindStringSubmatch(msg)
+	roles := make([]roleLimit, 0, len(plc.Spec.ClusterRoles))
 
-	if len(regexGroups) < 1 {
-		return 0, fmt.Errorf("could not find userCount in message")
+	for _, role := range plc.Spec.ClusterRoles {
+		roles = append(roles, roleLimit{role.Name, getAggregatedClusterRoles(clusterRoles, role.Name), role.MaxUsers})
 	}
 
-	return strconv.Atoi(regexGroups[1])
+	return roles
 }
 
-func getContainerID(pod corev1.Pod, containerName string) string {
-	for _, containerStatus := range pod.Status.ContainerStatuses {
+// listClusterRoles lists the ClusterRoles, which are needed to resolve aggregated ClusterRoles and the
+// privileged rules.
+func (e *Evaluator) listClusterRoles() ([]v1.ClusterRole, error) {
+	if listers := e.syncedListers.Load(); listers != nil {
+		return listCachedClusterRoles(listers)
+	}
+
+	clusterRoleList, err := e.TargetClient.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the ClusterRoles: %w", err)
+	}
+
+	return clusterRoleList.Items, nil
+}
+
+// listClusterRoleBindings returns the ClusterRoleBindings of the target cluster, from the informer cache once
+// it is synced. The cached ClusterRoleBindings are copied since they are modified when enforcing.
+func (e *Evaluator) listClusterRoleBindings() (*v1.ClusterRoleBindingList, error) {
+	listers := e.syncedListers.Load()
+	if listers == nil {
+		return e.TargetClient.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{})
+	}
+
+	cached, err := listers.clusterRoleBindings.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	clusterRoleBindingList := &v1.ClusterRoleBindingList{Items: make([]v1.ClusterRoleBinding, 0, len(cached))}
+
+	for _, clusterRoleBinding := range cached {
+		clusterRoleBindingList.Items = append(clusterRoleBindingList.Items, *clusterRoleBinding.DeepCopy())
+	}
+
+	return clusterRoleBindingList, nil
+}
+
+// getDetailsKey returns the CompliancyDetails key of a role in a scope, which is either cluster-wide or a
+// namespace. When the policy uses clusterRoles, the role name is appended to the scope as <scope>/<role>
+// so that each role is reported separately. Otherwise, the key is the scope.
+func getDetailsKey(plc *iampolicyv1.IamPolicy, scope string, roleName string) string {
+	if len(plc.Spec.ClusterRoles) == 0 || len(plc.Spec.PrivilegedRules) != 0 {
+		return scope
+	}
+
+	return scope + "/" + roleName
+}
+
+// getDetailsKeyRole returns the role name that a CompliancyDetails key is for.
+func getDetailsKeyRole(plc *iampolicyv1.IamPolicy, key string) string {
+	if len(plc.Spec.PrivilegedRules) != 0 {
+		return privilegedRoleName
+	}
+
+	if len(plc.Spec.ClusterRoles) == 0 {
+		return getClusterRoleRef(plc)
+	}
+
+	return key[strings.LastIndex(key, "/")+1:]
+}
+
+func isClusterWideKey(key string) bool {
+	return key == clusterWideKey || strings.HasPrefix(key, clusterWideKey+"/")
+}
+
+// getGroupMembership queries the group resolver for the membership of a group. An error wrapping
+// groups.ErrGroupNotFound or groups.ErrInvalidGroup is returned when the group is not found or is malformed,
+// and any other error is returned when the query itself failed.
+func (e *Evaluator) getGroupMembership(group string) ([]string, error) {
+	resolver := e.GroupResolver
+	if resolver == nil {
+		resolver = &groups.OpenShiftResolver{Client: e.TargetDynamicClient}
+	}
+
+	return resolver.GetMembers(context.TODO(), group)
+}
+
+// roleGrant is a subject of a ClusterRoleBinding or RoleBinding that grants the evaluated ClusterRole,
+// along with the users that the subject resolves to.
+type roleGrant struct {
+	// The ClusterRoleBinding granting the ClusterRole, nil when it is granted by a RoleBinding
+	binding     *v1.ClusterRoleBinding
+	bindingName string
+	// index of the subject in the binding subjects
+	index   int
+	subject v1.Subject
+	users   []string
+	// The time until which the binding is approved, zero when it has no approved-until annotation
+	approvedUntil time.Time
+	// Whether the approval of the binding expired, in which case it is a violation
+	approvalExpired bool
+	// The name of the active IamPolicyException that applies to the subject, empty when there is none
+	exception string
+	// Whether the subject is a group whose users couldn't be resolved
+	unresolved bool
+	// The error of the group resolver when it failed, rather than not finding the group
+	resolveErr error
+}
+
+// isApproved returns whether the grant is approved by an approved-until annotation that hasn't expired or by
+// an active IamPolicyException, in which case it is not counted against the limit.
+func (grant roleGrant) isApproved() bool {
+	return grant.exception != "" || !grant.approvedUntil.IsZero() && !grant.approvalExpired
+}
+
+// listPolicyExceptions returns the IamPolicyExceptions keyed by the namespace and the name of the policy they
+// apply to, in the same format as the e.availablePolicies keys, and sorted by name.
+func (e *Evaluator) listPolicyExceptions() (map[string][]iampolicyv1.IamPolicyException, error) {
+	// The exceptions are on the same cluster as the policies, so they can't be listed without its client
+	if e.Client == nil {
+		return nil, nil
+	}
+
+	exceptionList := &iampolicyv1.IamPolicyExceptionList{}
+
+	err := e.Client.List(context.TODO(), exceptionList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the IamPolicyExceptions: %w", err)
+	}
+
+	sort.Slice(exceptionList.Items, func(i, j int) bool {
+		return exceptionList.Items[i].Name < exceptionList.Items[j].Name
+	})
+
+	exceptions := map[string][]iampolicyv1.IamPolicyException{}
+
+	for _, exception := range exceptionList.Items {
+		key := fmt.Sprintf("%s.%s", exception.Namespace, exception.Spec.PolicyName)
+		exceptions[key] = append(exceptions[key], exception)
+	}
+
+	return exceptions, nil
+}
+
+// applyExceptions sets the first active IamPolicyException that applies to the subject of each grant of a
+// binding. The clusterRoleBinding is empty for RoleBindings, and the bindingNamespace is the namespace of
+// ServiceAccount subjects without one. An exception takes precedence over an expired approval.
+func applyExceptions(
+	grants []roleGrant,
+	exceptions []iampolicyv1.IamPolicyException,
+	clusterRoleBinding string,
+	bindingNamespace string,
+	now time.Time,
+) {
+	for i := range grants {
+		name := grants[i].subject.Name
+
+		if grants[i].subject.Kind == "ServiceAccount" {
+			namespace := grants[i].subject.Namespace
+			if namespace == "" {
+				namespace = bindingNamespace
+			}
+
+			name = namespace + ":" + name
+		}
+
+		for j := range exceptions {
+			exception := &exceptions[j]
+
+			if exception.IsActive(now) && exception.Matches(clusterRoleBinding, grants[i].subject.Kind, name) {
+				grants[i].exception = exception.Name
+				grants[i].approvalExpired = false
+
+				break
+			}
+		}
+	}
+}
+
+// applyUnresolvableGroups counts each unresolved group of the grants as a single user when the mode is
+// CountAsOne. The other modes are applied when setting the violations and the compliance of the policy.
+func applyUnresolvableGroups(grants []roleGrant, mode iampolicyv1.UnresolvableGroupsMode) {
+	if mode != iampolicyv1.CountAsOne {
+		return
+	}
+
+	for i := range grants {
+		if grants[i].unresolved {
+			grants[i].users = []string{unresolvedGroupUserPrefix + grants[i].subject.Name}
+		}
+	}
+}
+
+// getUnresolvedGroups returns the sorted names of the unresolved groups of the grants that are not approved.
+func getUnresolvedGroups(grants []roleGrant) []string {
+	var unresolved []string
+
+	for _, grant := range grants {
+		if grant.unresolved && !grant.isApproved() && !slices.Contains(unresolved, grant.subject.Name) {
+			unresolved = append(unresolved, grant.subject.Name)
+		}
+	}
+
+	sort.Strings(unresolved)
+
+	return unresolved
+}
+
+// getResolveErrors returns an evaluation error for each unresolved group of the grants whose lookup failed,
+// since the users of the group may make the policy non-compliant.
+func getResolveErrors(grants []roleGrant) []string {
+	var resolveErrors []string
+
+	for _, grant := range grants {
+		if grant.resolveErr == nil || grant.isApproved() {
+			continue
+		}
+
+		msg := fmt.Sprintf("failed to resolve the users of the group %s: %v", grant.subject.Name, grant.resolveErr)
+		if !slices.Contains(resolveErrors, msg) {
+			resolveErrors = append(resolveErrors, msg)
+		}
+	}
+
+	return resolveErrors
+}
+
+// getAppliedExceptions returns the sorted names of the IamPolicyExceptions applied to the grants.
+func getAppliedExceptions(grants []roleGrant) []string {
+	var applied []string
+
+	for _, grant := range grants {
+		if grant.exception != "" && !slices.Contains(applied, grant.exception) {
+			applied = append(applied, grant.exception)
+		}
+	}
+
+	sort.Strings(applied)
+
+	return applied
+}
+
+// getApprovedUntil returns the time of the approved-until annotation of the ClusterRoleBinding, or the zero
+// time if approvals are not allowed, if it is not set, if it is not a valid RFC 3339 time, or if it is more than
+// maxApproval after now.
+func getApprovedUntil(binding *v1.ClusterRoleBinding, now time.Time, maxApproval time.Duration) time.Time {
+	value, ok := binding.Annotations[approvedUntilAnnotation]
+	if !ok || maxApproval <= 0 {
+		return time.Time{}
+	}
+
+	approvedUntil, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		log.Error(err, "Ignoring the invalid approved-until annotation, the subjects are counted",
+			"ClusterRoleBinding", binding.Name, "Annotation", approvedUntilAnnotation)
+
+		return time.Time{}
+	}
+
+	if approvedUntil.After(now.Add(maxApproval)) {
+		log.Info("Ignoring the approved-until annotation beyond the maximum approval duration, the subjects are "+
+			"counted", "ClusterRoleBinding", binding.Name, "ApprovedUntil", value, "MaxApprovalDuration", maxApproval)
+
+		return time.Time{}
+	}
+
+	return approvedUntil
+}
+
+// compileIgnoreBindings compiles the ignoreClusterRoleBindings regular expressions, defaulting to the
+// regular expression that ignores bindings starting with system: when none are specified.
+func compileIgnoreBindings(ignoreCRBs []iampolicyv1.NonEmptyString) ([]*regexp.Regexp, error) {
+	if len(ignoreCRBs) == 0 {
+		ignoreCRBs = []iampolicyv1.NonEmptyString{defaultIgnoreCRBs}
+	}
+
+	compiledIgnoreCRBs := make([]*regexp.Regexp, 0, len(ignoreCRBs))
+
+	for _, regex := range ignoreCRBs {
+		regex, err := regexp.Compile(string(regex))
+		if err != nil {
+			err = fmt.Errorf("ignoreClusterRoleBindings value '%s' is not a valid regular expression: %w", regex, err)
+
+			return nil, err
+		}
+
+		compiledIgnoreCRBs = append(compiledIgnoreCRBs, regex)
+	}
+
+	return compiledIgnoreCRBs, nil
+}
+
+// ignoredSubjects is the compiled ignoreSubjects regular expressions keyed by subject kind.
+type ignoredSubjects map[string][]*regexp.Regexp
+
+// compileIgnoreSubjects compiles the ignoreSubjects regular expressions.
+func compileIgnoreSubjects(ignoreSubjects []iampolicyv1.IgnoredSubject) (ignoredSubjects, error) {
+	compiled := ignoredSubjects{}
+
+	for _, ignoreSubject := range ignoreSubjects {
+		regex, err := regexp.Compile(string(ignoreSubject.Name))
+		if err != nil {
+			return nil, fmt.Errorf(
+				"ignoreSubjects value '%s' is not a valid regular expression: %w", ignoreSubject.Name, err,
+			)
+		}
+
+		compiled[ignoreSubject.Kind] = append(compiled[ignoreSubject.Kind], regex)
+	}
+
+	return compiled, nil
+}
+
+// matches returns whether the name of a subject of the kind matches one of the ignoreSubjects values. The
+// name of a ServiceAccount is in the <namespace>:<name> format.
+func (ignored ignoredSubjects) matches(kind string, name string) bool {
+	for _, regex := range ignored[kind] {
+		if regex.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isIgnoredBinding(compiledIgnoreCRBs []*regexp.Regexp, bindingName string) bool {
+	for _, regex := range compiledIgnoreCRBs {
+		if regex.MatchString(bindingName) {
+			log.Info(fmt.Sprintf("ignoreClusterRoleBinding entry '%s' matched '%s'. Skipping.",
+				regex, bindingName))
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// getSubjectGrants returns a grant for each User and Group subject of a binding that references the
+// ClusterRole, and for each ServiceAccount subject if includeServiceAccounts is set. A ServiceAccount
+// resolves to the system:serviceaccount:<namespace>:<name> user. The bindingNamespace is the namespace
+// of ServiceAccount subjects without one, and is empty for ClusterRoleBindings. If the members of a
+// group can't be retrieved, the group is unresolved and has no users. The subjects matching ignoreSubjects
+// are skipped, as well as the users resolved from groups that match the User values, and they are returned
+// in the <kind>:<name> format.
+func (e *Evaluator) getSubjectGrants(
+	bindingName string,
+	bindingNamespace string,
+	subjects []v1.Subject,
+	clusterroleref string,
+	includeServiceAccounts bool,
+	ignored ignoredSubjects,
+) (grants []roleGrant, ignoredNames []string) {
+	grants = []roleGrant{}
+
+	for i, subject := range subjects {
+		if subject.Kind == "ServiceAccount" {
+			if !includeServiceAccounts {
+				continue
+			}
+
+			namespace := subject.Namespace
+			if namespace == "" {
+				namespace = bindingNamespace
+			}
+
+			if ignored.matches(subject.Kind, namespace+":"+subject.Name) {
+				ignoredNames = append(ignoredNames, subject.Kind+":"+namespace+":"+subject.Name)
+
+				continue
+			}
+
+			user := serviceAccountUserPrefix + namespace + ":" + subject.Name
+			grants = append(grants, roleGrant{
+				bindingName: bindingName, index: i, subject: subject, users: []string{user},
+			})
+		} else if subject.Kind == "User" {
+			if ignored.matches(subject.Kind, subject.Name) {
+				ignoredNames = append(ignoredNames, subject.Kind+":"+subject.Name)
+
+				continue
+			}
+
+			grants = append(grants, roleGrant{
+				bindingName: bindingName, index: i, subject: subject, users: []string{subject.Name},
+			})
+		} else if subject.Kind == "Group" {
+			if ignored.matches(subject.Kind, subject.Name) {
+				ignoredNames = append(ignoredNames, subject.Kind+":"+subject.Name)
+
+				continue
+			}
+
+			users, err := e.getGroupMembership(subject.Name)
+			if err != nil {
+				grant := roleGrant{
+					bindingName: bindingName, index: i, subject: subject, users: []string{}, unresolved: true,
+				}
+
+				if stderrors.Is(err, groups.ErrGroupNotFound) || stderrors.Is(err, groups.ErrInvalidGroup) {
+					log.Info(fmt.Sprintf("Could not retrieve users from group '%s': %v", subject.Name, err),
+						"Binding", bindingName, "ClusterRole", clusterroleref)
+				} else {
+					log.Error(err, "Error retrieving users in group (policy compliance will be unknown)",
+						"Binding", bindingName, "ClusterRole", clusterroleref, "Group", subject.Name)
+
+					grant.resolveErr = err
+				}
+
+				grants = append(grants, grant)
+
+				continue
+			}
+
+			countedUsers := make([]string, 0, len(users))
+
+			for _, user := range users {
+				if ignored.matches("User", user) {
+					ignoredNames = append(ignoredNames, "User:"+user)
+
+					continue
+				}
+
+				countedUsers = append(countedUsers, user)
+			}
+
+			grants = append(grants, roleGrant{
+				bindingName: bindingName, index: i, subject: subject, users: countedUsers,
+			})
+		}
+	}
+
+	return grants, ignoredNames
+}
+
+// countUnique returns the number of unique values.
+func countUnique(values []string) int {
+	unique := make(map[string]bool, len(values))
+
+	for _, value := range values {
+		unique[value] = true
+	}
+
+	return len(unique)
+}
+
+// countUsers returns the number of unique users granted the ClusterRole by the grants, excluding the
+// grants that are approved by an approved-until annotation that hasn't expired.
+func countUsers(grants []roleGrant) int {
+	usersMap := make(map[string]bool)
+
+	for _, grant := range grants {
+		if grant.isApproved() {
+			continue
+		}
+
+		for _, user := range grant.users {
+			usersMap[user] = true
+		}
+	}
+
+	return len(usersMap)
+}
+
+// checkAllClusterLevel returns the subjects of the ClusterRoleBindings that are not ignored and that
+// reference one of the ClusterRoles, along with the number of unique users they grant the ClusterRoles to
+// and the number of unique subjects that matched ignoreSubjects. The users of ClusterRoleBindings with an
+// approved-until annotation in the future and of the subjects of active exceptions are not counted. The
+// approved-until annotations are ignored when maxApproval is zero.
+func (e *Evaluator) checkAllClusterLevel(
+	clusterRoleBindingList *v1.ClusterRoleBindingList,
+	clusterRoleRefs []string,
+	ignoreCRBs []iampolicyv1.NonEmptyString,
+	ignoreSubjects []iampolicyv1.IgnoredSubject,
+	includeServiceAccounts bool,
+	exceptions []iampolicyv1.IamPolicyException,
+	maxApproval time.Duration,
+	unresolvable iampolicyv1.UnresolvableGroupsMode,
+) (grants []roleGrant, userV int, ignoredV int, err error) {
+	compiledIgnoreCRBs, err := compileIgnoreBindings(ignoreCRBs)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	ignored, err := compileIgnoreSubjects(ignoreSubjects)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	var ignoredNames []string
+
+	now := time.Now()
+
+	for i := range clusterRoleBindingList.Items {
+		clusterRoleBinding := &clusterRoleBindingList.Items[i]
+
+		if isIgnoredBinding(compiledIgnoreCRBs, clusterRoleBinding.Name) {
+			continue
+		}
+
+		// Only consider role bindings with matching referenced cluster role
+		roleRef := clusterRoleBinding.RoleRef
+		if roleRef.Kind == "ClusterRole" && slices.Contains(clusterRoleRefs, roleRef.Name) {
+			bindingGrants, bindingIgnored := e.getSubjectGrants(
+				clusterRoleBinding.Name, "", clusterRoleBinding.Subjects, roleRef.Name, includeServiceAccounts, ignored,
+			)
+
+			approvedUntil := getApprovedUntil(clusterRoleBinding, now, maxApproval)
+
+			for i := range bindingGrants {
+				bindingGrants[i].binding = clusterRoleBinding
+				bindingGrants[i].approvedUntil = approvedUntil
+				bindingGrants[i].approvalExpired = !approvedUntil.IsZero() && !now.Before(approvedUntil)
+			}
+
+			applyExceptions(bindingGrants, exceptions, clusterRoleBinding.Name, "", now)
+			applyUnresolvableGroups(bindingGrants, unresolvable)
+
+			grants = append(grants, bindingGrants...)
+
+			ignoredNames = append(ignoredNames, bindingIgnored...)
+		}
+	}
+
+	return grants, countUsers(grants), countUnique(ignoredNames), nil
+}
+
+// checkNamespaceLevel returns the subjects of the RoleBindings that are not ignored and that reference
+// one of the ClusterRoles, along with the number of unique users they grant the ClusterRoles to and the
+// number of unique subjects that matched ignoreSubjects. The users of the subjects of active exceptions
+// without a clusterRoleBinding are not counted.
+func (e *Evaluator) checkNamespaceLevel(
+	roleBindings []v1.RoleBinding,
+	clusterRoleRefs []string,
+	ignoreCRBs []iampolicyv1.NonEmptyString,
+	ignoreSubjects []iampolicyv1.IgnoredSubject,
+	includeServiceAccounts bool,
+	exceptions []iampolicyv1.IamPolicyException,
+	unresolvable iampolicyv1.UnresolvableGroupsMode,
+) (grants []roleGrant, userV int, ignoredV int, err error) {
+	compiledIgnoreCRBs, err := compileIgnoreBindings(ignoreCRBs)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	ignored, err := compileIgnoreSubjects(ignoreSubjects)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	var ignoredNames []string
+
+	now := time.Now()
+
+	for _, roleBinding := range roleBindings {
+		if isIgnoredBinding(compiledIgnoreCRBs, roleBinding.Name) {
+			continue
+		}
+
+		roleRef := roleBinding.RoleRef
+		if roleRef.Kind == "ClusterRole" && slices.Contains(clusterRoleRefs, roleRef.Name) {
+			bindingGrants, bindingIgnored := e.getSubjectGrants(
+				roleBinding.Name,
+				roleBinding.Namespace,
+				roleBinding.Subjects,
+				roleRef.Name,
+				includeServiceAccounts,
+				ignored,
+			)
+
+			applyExceptions(bindingGrants, exceptions, "", roleBinding.Namespace, now)
+			applyUnresolvableGroups(bindingGrants, unresolvable)
+
+			grants = append(grants, bindingGrants...)
+			ignoredNames = append(ignoredNames, bindingIgnored...)
+		}
+	}
+
+	return grants, countUsers(grants), countUnique(ignoredNames), nil
+}
+
+// getApprovedUsers returns the sorted unique users granted the ClusterRole only by grants that are approved
+// by an approved-until annotation that hasn't expired or by an exception.
+func getApprovedUsers(grants []roleGrant) []string {
+	approvedUsers := map[string]bool{}
+
+	for _, grant := range grants {
+		if grant.isApproved() {
+			for _, user := range grant.users {
+				approvedUsers[user] = true
+			}
+		}
+	}
+
+	for _, grant := range grants {
+		if !grant.isApproved() {
+			for _, user := range grant.users {
+				delete(approvedUsers, user)
+			}
+		}
+	}
+
+	if len(approvedUsers) == 0 {
+		return nil
+	}
+
+	users := make([]string, 0, len(approvedUsers))
+	for user := range approvedUsers {
+		users = append(users, user)
+	}
+
+	slices.Sort(users)
+
+	return users
+}
+
+// hasExpiredApproval returns whether any of the grants is from a ClusterRoleBinding whose approval expired.
+func hasExpiredApproval(grants []roleGrant) bool {
+	return slices.ContainsFunc(grants, func(grant roleGrant) bool { return grant.approvalExpired })
+}
+
+func isEnforce(plc *iampolicyv1.IamPolicy) bool {
+	return strings.EqualFold(string(plc.Spec.RemediationAction), string(iampolicyv1.Enforce))
+}
+
+// getMaxApproval returns the longest time an approval of a ClusterRoleBinding can extend into the future, or
+// zero when the policy doesn't allow approvals. An invalid maxApprovalDuration disallows approvals.
+func getMaxApproval(plc *iampolicyv1.IamPolicy) time.Duration {
+	maxApproval, err := plc.Spec.GetMaxApprovalDuration()
+	if err != nil {
+		log.Error(err, "Ignoring the approved-until annotations", "Name", plc.Name)
+
+		return 0
+	}
+
+	return maxApproval
+}
+
+// removeExcessSubjects removes the subjects of the ClusterRoleBindings whose approval expired, then removes
+// subjects from the ClusterRoleBindings in the grants until no more than maxUsers unique users are granted
+// the ClusterRole. The subjects of the most recently created ClusterRoleBindings are removed first, and
+// within a ClusterRoleBinding, the subjects are removed from last to first, but a subject is only removed when
+// it lowers the number of users, alone or with the other subjects granting the same users. Subjects that don't
+// resolve to any users are left in place, unless their approval expired, and approved subjects are never
+// removed. The removed subjects are returned, even if updating one of the ClusterRoleBindings failed.
+func (e *Evaluator) removeExcessSubjects(grants []roleGrant, maxUsers int) ([]iampolicyv1.RemovedSubject, error) {
+	userGrants := map[string]int{}
+
+	for _, grant := range grants {
+		if grant.isApproved() {
+			continue
+		}
+
+		for _, user := range grant.users {
+			userGrants[user]++
+		}
+	}
+
+	// Only the subjects of ClusterRoleBindings are removed, and never the groups whose users are unknown
+	// unless their approval expired
+	ordered := make([]roleGrant, 0, len(grants))
+
+	for _, grant := range grants {
+		if grant.binding != nil && !grant.isApproved() && (!grant.unresolved || grant.approvalExpired) {
+			ordered = append(ordered, grant)
+		}
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].approvalExpired != ordered[j].approvalExpired {
+			return ordered[i].approvalExpired
+		}
+
+		bindingI, bindingJ := ordered[i].binding, ordered[j].binding
+
+		if !bindingI.CreationTimestamp.Equal(&bindingJ.CreationTimestamp) {
+			return bindingJ.CreationTimestamp.Before(&bindingI.CreationTimestamp)
+		}
+
+		if bindingI.Name != bindingJ.Name {
+			return bindingI.Name < bindingJ.Name
+		}
+
+		return ordered[i].index > ordered[j].index
+	})
+
+	// The subject indexes to remove in removal order, keyed by ClusterRoleBinding name
+	toRemove := map[string][]int{}
+	// The ClusterRoleBinding names in the order they are first modified
+	bindingOrder := []string{}
+	bindings := map[string]*v1.ClusterRoleBinding{}
+
+	removedGrants := make([]bool, len(ordered))
+
+	removeGrant := func(i int) {
+		grant := ordered[i]
+		removedGrants[i] = true
+
+		for _, user := range grant.users {
+			userGrants[user]--
+
+			if userGrants[user] == 0 {
+				delete(userGrants, user)
+			}
+		}
+
+		if _, ok := toRemove[grant.binding.Name]; !ok {
+			bindingOrder = append(bindingOrder, grant.binding.Name)
+			bindings[grant.binding.Name] = grant.binding
+		}
+
+		toRemove[grant.binding.Name] = append(toRemove[grant.binding.Name], grant.index)
+	}
+
+	for i, grant := range ordered {
+		if grant.approvalExpired {
+			removeGrant(i)
+		}
+	}
+
+	// The grants whose removal lowers the number of users are removed first. Since removing one of the grants of
+	// a user granted the ClusterRole several times doesn't, the grants of such users are then removed together
+	// with the other removable grants of only the same users.
+	for _, withOtherGrants := range []bool{false, true} {
+		for i, grant := range ordered {
+			if len(userGrants) <= maxUsers {
+				break
+			}
+
+			if removedGrants[i] || len(grant.users) == 0 {
+				continue
+			}
+
+			indexes := []int{i}
+
+			if withOtherGrants {
+				for j, other := range ordered {
+					if j != i && !removedGrants[j] && len(other.users) != 0 && isSubset(other.users, grant.users) {
+						indexes = append(indexes, j)
+					}
+				}
+			}
+
+			if !lowersUserCount(ordered, indexes, userGrants) {
+				continue
+			}
+
+			for _, j := range indexes {
+				removeGrant(j)
+			}
+		}
+	}
+
+	removed := []iampolicyv1.RemovedSubject{}
+
+	for _, bindingName := range bindingOrder {
+		binding := bindings[bindingName].DeepCopy()
+		removeIndexes := map[int]bool{}
+
+		for _, index := range toRemove[bindingName] {
+			removeIndexes[index] = true
+		}
+
+		originalSubjects := binding.Subjects
+		subjects := make([]v1.Subject, 0, len(originalSubjects))
+
+		for i, subject := range originalSubjects {
+			if !removeIndexes[i] {
+				subjects = append(subjects, subject)
+			}
+		}
+
+		binding.Subjects = subjects
+
+		_, err := e.TargetClient.RbacV1().ClusterRoleBindings().Update(
+			context.TODO(), binding, metav1.UpdateOptions{},
+		)
+		if err != nil {
+			return removed, fmt.Errorf("failed to update the ClusterRoleBinding %s: %w", bindingName, err)
+		}
+
+		// Keep the cached ClusterRoleBinding in sync for the other policies evaluated in this loop
+		bindings[bindingName].Subjects = subjects
+
+		for _, index := range toRemove[bindingName] {
+			subject := originalSubjects[index]
+			removed = append(removed, iampolicyv1.RemovedSubject{
+				ClusterRoleBinding: bindingName,
+				Kind:               subject.Kind,
+				Name:               subject.Name,
+				Namespace:          subject.Namespace,
+				RemovalTime:        metav1.Now(),
+			})
+		}
+	}
+
+	return removed, nil
+}
+
+// isSubset returns whether all the users are in the superset.
+func isSubset(users []string, superset []string) bool {
+	for _, user := range users {
+		if !slices.Contains(superset, user) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// lowersUserCount returns whether removing the grants at the indexes removes all the grants of at least one
+// user, given the number of grants of each user.
+func lowersUserCount(grants []roleGrant, indexes []int, userGrants map[string]int) bool {
+	removedGrants := map[string]int{}
+
+	for _, i := range indexes {
+		for _, user := range grants[i].users {
+			removedGrants[user]++
+		}
+	}
+
+	for user, count := range removedGrants {
+		if userGrants[user] <= count {
+			return true
+		}
+	}
+
+	return false
+}
+
+// recordRemovedSubjects logs and emits an event for each subject removed when enforcing the policy.
+func (e *Evaluator) recordRemovedSubjects(
+	plc *iampolicyv1.IamPolicy, roleName string, removed []iampolicyv1.RemovedSubject,
+) {
+	for _, subject := range removed {
+		log.Info("Removed a subject from a ClusterRoleBinding to enforce the policy", "Name", plc.Name,
+			"ClusterRole", roleName, "ClusterRoleBinding", subject.ClusterRoleBinding, "Kind", subject.Kind,
+			"Subject", subject.Name)
+
+		if e.Recorder == nil {
+			continue
+		}
+
+		e.Recorder.Event(plc, corev1.EventTypeNormal, "SubjectRemoved",
+			fmt.Sprintf("Removed the %s %s from the ClusterRoleBinding %s to enforce the limit on the %s role",
+				subject.Kind, subject.Name, subject.ClusterRoleBinding, roleName))
+	}
+}
+
+func (e *Evaluator) convertMaptoPolicyNameKey() map[string]*iampolicyv1.IamPolicy {
+	plcMap := make(map[string]*iampolicyv1.IamPolicy)
+
+	e.availablePolicies.Mx.RLock()
+	defer e.availablePolicies.Mx.RUnlock()
+
+	for key, policy := range e.availablePolicies.PolicyMap {
+		// Skip the policies that are not due for evaluation in the current loop
+		if e.duePolicies != nil && !e.duePolicies[key] {
+			continue
+		}
+
+		plcMap[fmt.Sprintf("%s.%s", policy.Namespace, policy.Name)] = policy
+	}
+
+	return plcMap
+}
+
+// newRoleEvaluation returns the evaluation of the users bound to the role in the scope.
+func newRoleEvaluation(scope string, role roleLimit, subjectCount int) iampolicyv1.RoleEvaluation {
+	evaluation := iampolicyv1.RoleEvaluation{
+		Scope:        scope,
+		Role:         role.name,
+		SubjectCount: subjectCount,
+		Limit:        role.maxUsers,
+	}
+
+	if role.maxUsers < subjectCount && role.maxUsers >= 0 {
+		evaluation.Excess = subjectCount - role.maxUsers
+	}
+
+	return evaluation
+}
+
+// newRoleEvaluationError returns the evaluation of a role in the scope that failed with the error.
+func newRoleEvaluationError(scope string, role roleLimit, err error) iampolicyv1.RoleEvaluation {
+	evaluation := newRoleEvaluation(scope, role, 0)
+	evaluation.EvaluationErrors = []string{err.Error()}
+
+	return evaluation
+}
+
+// setEvaluationViolations sets the allowed and forbidden subject violations of the grants in the evaluation,
+// or an evaluation error if they could not be determined. The unresolved groups of the evaluation are
+// violations when the policy counts them as unlimited users.
+func setEvaluationViolations(
+	evaluation *iampolicyv1.RoleEvaluation, grants []roleGrant, spec *iampolicyv1.IamPolicySpec,
+) {
+	violations, err := getSubjectViolations(grants, evaluation.Role, spec)
+	if err != nil {
+		log.Error(err, "Error checking the users bound to ClusterRole", "ClusterRole", evaluation.Role,
+			"Scope", evaluation.Scope)
+
+		evaluation.EvaluationErrors = append(evaluation.EvaluationErrors, err.Error())
+
+		return
+	}
+
+	if len(evaluation.UnresolvedGroups) != 0 && spec.UnresolvableGroups == iampolicyv1.CountAsUnlimited {
+		violations = append(violations, fmt.Sprintf(
+			violationMsgFUnresolved, strings.Join(evaluation.UnresolvedGroups, ", "), evaluation.Role,
+		))
+	}
+
+	if len(violations) != 0 {
+		evaluation.Violations = violations
+	}
+}
+
+// getEvaluationMessages returns the human readable CompliancyDetails messages of the evaluation, which are
+// the user count message followed by the violations and the evaluation errors.
+func getEvaluationMessages(evaluation iampolicyv1.RoleEvaluation) []string {
+	messages := []string{fmt.Sprintf(violationMsgF, evaluation.Role, fmt.Sprint(evaluation.Excess))}
+	messages = append(messages, evaluation.Violations...)
+
+	for _, evaluationErr := range evaluation.EvaluationErrors {
+		messages = append(messages, fmt.Sprintf(evaluationErrMsgF, evaluation.Role, evaluationErr))
+	}
+
+	return messages
+}
+
+// setRoleEvaluation sets the evaluation in the policy status, replacing the previous evaluation of the role
+// in the scope, and sets the CompliancyDetails messages derived from it. It returns whether the status
+// changed.
+func setRoleEvaluation(plc *iampolicyv1.IamPolicy, evaluation iampolicyv1.RoleEvaluation) (changed bool) {
+	key := getDetailsKey(plc, evaluation.Scope, evaluation.Role)
+	messages := getEvaluationMessages(evaluation)
+
+	if plc.Status.CompliancyDetails == nil {
+		plc.Status.CompliancyDetails = make(map[string]iampolicyv1.CompliancyDetail)
+	}
+
+	if _, ok := plc.Status.CompliancyDetails[plc.Name]; !ok {
+		plc.Status.CompliancyDetails[plc.Name] = make(map[string][]string)
+	}
+
+	if !slices.Equal(plc.Status.CompliancyDetails[plc.Name][key], messages) {
+		plc.Status.CompliancyDetails[plc.Name][key] = messages
+		changed = true
+	}
+
+	index := slices.IndexFunc(plc.Status.Evaluations, func(existing iampolicyv1.RoleEvaluation) bool {
+		return existing.Scope == evaluation.Scope && existing.Role == evaluation.Role
+	})
+
+	if index == -1 {
+		plc.Status.Evaluations = append(plc.Status.Evaluations, evaluation)
+		sort.SliceStable(plc.Status.Evaluations, func(i, j int) bool {
+			if plc.Status.Evaluations[i].Scope != plc.Status.Evaluations[j].Scope {
+				return plc.Status.Evaluations[i].Scope < plc.Status.Evaluations[j].Scope
+			}
+
+			return plc.Status.Evaluations[i].Role < plc.Status.Evaluations[j].Role
+		})
+
+		return true
+	}
+
+	if !equality.Semantic.DeepEqual(plc.Status.Evaluations[index], evaluation) {
+		plc.Status.Evaluations[index] = evaluation
+		changed = true
+	}
+
+	return changed
+}
+
+// getSubjectViolations returns the violation messages for the subjects granted the ClusterRole that are
+// not allowed or that are forbidden by the policy, and for the subjects whose approval expired.
+func getSubjectViolations(grants []roleGrant, roleName string, spec *iampolicyv1.IamPolicySpec) ([]string, error) {
+	violations := getNotAllowedViolations(grants, roleName, spec)
+
+	forbiddenViolations, err := getForbiddenViolations(grants, roleName, spec.ForbiddenSubjects)
+	if err != nil {
+		return nil, err
+	}
+
+	violations = append(violations, forbiddenViolations...)
+
+	return append(violations, getExpiredApprovalViolations(grants, roleName)...), nil
+}
+
+// getExpiredApprovalViolations returns a violation message for each subject granted the ClusterRole by a
+// ClusterRoleBinding whose approved-until annotation has expired.
+func getExpiredApprovalViolations(grants []roleGrant, roleName string) []string {
+	violations := []string{}
+
+	for _, grant := range grants {
+		if grant.approvalExpired {
+			violations = append(violations, fmt.Sprintf(violationMsgFExpired, grant.subject.Kind+" "+grant.subject.Name,
+				roleName, grant.bindingName, grant.approvedUntil.Format(time.RFC3339)))
+		}
+	}
+
+	return violations
+}
+
+// getNotAllowedViolations returns a violation message for the users granted the ClusterRole that are not
+// approved by the allowed lists of the policy. A user is approved if it is in allowedUsers, if it is a
+// ServiceAccount in allowedServiceAccounts, if it is granted the ClusterRole through a group in
+// allowedGroups, or if it is granted the ClusterRole by a ClusterRoleBinding with an approved-until
+// annotation that hasn't expired. Nothing is returned if none of the allowed lists are set.
+func getNotAllowedViolations(grants []roleGrant, roleName string, spec *iampolicyv1.IamPolicySpec) []string {
+	violations := []string{}
+
+	if len(spec.AllowedUsers) == 0 && len(spec.AllowedGroups) == 0 && len(spec.AllowedServiceAccounts) == 0 {
+		return violations
+	}
+
+	allowedUsers := map[string]bool{}
+
+	for _, user := range spec.AllowedUsers {
+		allowedUsers[string(user)] = true
+	}
+
+	for _, serviceAccount := range spec.AllowedServiceAccounts {
+		allowedUsers[serviceAccountUserPrefix+string(serviceAccount)] = true
+	}
+
+	allowedGroups := map[string]bool{}
+
+	for _, group := range spec.AllowedGroups {
+		allowedGroups[string(group)] = true
+	}
+
+	// The users granted the ClusterRole and whether they are approved
+	usersMap := map[string]bool{}
+
+	for _, grant := range grants {
+		grantAllowed := grant.isApproved() || grant.subject.Kind == "Group" && allowedGroups[grant.subject.Name]
+
+		for _, user := range grant.users {
+			usersMap[user] = usersMap[user] || grantAllowed || allowedUsers[user]
+		}
+	}
+
+	notAllowed := []string{}
+
+	for user, approved := range usersMap {
+		if !approved {
+			notAllowed = append(notAllowed, user)
+		}
+	}
+
+	if len(notAllowed) != 0 {
+		sort.Strings(notAllowed)
+		violations = append(violations, fmt.Sprintf(violationMsgFNotAllowed, roleName, strings.Join(notAllowed, ", ")))
+	}
+
+	return violations
+}
+
+// getForbiddenViolations returns a violation message for each subject granted the ClusterRole that
+// matches a forbiddenSubjects regular expression, naming the binding that granted it. The name of each
+// subject is matched, as well as the users that group subjects resolve to. The subjects approved by an
+// exception or an approved-until annotation are not violations.
+func getForbiddenViolations(
+	grants []roleGrant, roleName string, forbiddenSubjects []iampolicyv1.NonEmptyString,
+) ([]string, error) {
+	violations := []string{}
+
+	compiledForbidden := make([]*regexp.Regexp, 0, len(forbiddenSubjects))
+
+	for _, forbidden := range forbiddenSubjects {
+		regex, err := regexp.Compile(string(forbidden))
+		if err != nil {
+			return nil, fmt.Errorf("forbiddenSubjects value '%s' is not a valid regular expression: %w", forbidden, err)
+		}
+
+		compiledForbidden = append(compiledForbidden, regex)
+	}
+
+	matchForbidden := func(name string) *regexp.Regexp {
+		for _, regex := range compiledForbidden {
+			if regex.MatchString(name) {
+				return regex
+			}
+		}
+
+		return nil
+	}
+
+	for _, grant := range grants {
+		if grant.isApproved() {
+			continue
+		}
+
+		if grant.subject.Kind == "Group" {
+			if regex := matchForbidden(grant.subject.Name); regex != nil {
+				violations = append(violations, fmt.Sprintf(violationMsgFForbidden, "Group "+grant.subject.Name,
+					regex, roleName, grant.bindingName))
+			}
+
+			for _, user := range grant.users {
+				if regex := matchForbidden(user); regex != nil {
+					subject := fmt.Sprintf("User %s from the Group %s", user, grant.subject.Name)
+					violations = append(violations, fmt.Sprintf(violationMsgFForbidden, subject, regex, roleName,
+						grant.bindingName))
+				}
+			}
+
+			continue
+		}
+
+		// User and ServiceAccount grants resolve to a single user
+		for _, user := range grant.users {
+			if regex := matchForbidden(user); regex != nil {
+				violations = append(violations, fmt.Sprintf(violationMsgFForbidden, grant.subject.Kind+" "+user,
+					regex, roleName, grant.bindingName))
+			}
+		}
+	}
+
+	return violations, nil
+}
+
+// checkComplianceBasedOnEvaluations sets the compliance state from the evaluations in the status. The
+// policy is non-compliant if any role has users above its limit or subject violations. The compliance state
+// is left as is when no violation is found but an evaluation had errors, since the results are incomplete.
+// The compliance is unknown when no violation is found but groups couldn't be resolved and the policy treats
+// them as Unknown. It returns whether the compliance state changed.
+func checkComplianceBasedOnEvaluations(plc *iampolicyv1.IamPolicy) bool {
+	previousComplianceState := plc.Status.ComplianceState
+	evaluationErrors := false
+	unresolvedGroups := false
+
+	plc.Status.ComplianceState = iampolicyv1.Compliant
+
+	for _, evaluation := range plc.Status.Evaluations {
+		if evaluation.Excess > 0 || len(evaluation.Violations) != 0 {
+			plc.Status.ComplianceState = iampolicyv1.NonCompliant
+		}
+
+		if len(evaluation.EvaluationErrors) != 0 {
+			evaluationErrors = true
+		}
+
+		if len(evaluation.UnresolvedGroups) != 0 {
+			unresolvedGroups = true
+		}
+	}
+
+	// The users of the unresolved groups could make the policy non-compliant
+	if unresolvedGroups && plc.Spec.UnresolvableGroups == iampolicyv1.Unknown &&
+		plc.Status.ComplianceState == iampolicyv1.Compliant {
+		plc.Status.ComplianceState = iampolicyv1.UnknownCompliancy
+	}
+
+	if evaluationErrors && plc.Status.ComplianceState == iampolicyv1.Compliant {
+		log.Info("Not updating status to compliant due to evaluation errors.", "Name", plc.Name)
+
+		plc.Status.ComplianceState = previousComplianceState
+	}
+
+	return previousComplianceState != plc.Status.ComplianceState
+}
+
+// summarizePoliciesStatus sets the status conditions and records the compliance history of every policy
+// after they were evaluated. The errors returned by the evaluation of the ClusterRoleBindings and of the
+// namespaces mean that the policies relying on them could not be evaluated. It returns true if any policy
+// status changed.
+func (e *Evaluator) summarizePoliciesStatus(
+	plcToUpdateMap map[string]*iampolicyv1.IamPolicy, unNamespacedErr error, namespacedErr error,
+) bool {
+	update := false
+	now := time.Now()
+
+	for _, policy := range e.convertMaptoPolicyNameKey() {
+		evalErr := unNamespacedErr
+		if evalErr == nil && len(policy.Spec.NamespaceSelector.Include) != 0 {
+			evalErr = namespacedErr
+		}
+
+		if e.setUnknownComplianceOnFailure(policy, evalErr, now) {
+			plcToUpdateMap[policy.Name] = policy
+			update = true
+		}
+
+		if setStatusConditions(policy, evalErr) {
+			plcToUpdateMap[policy.Name] = policy
+			update = true
+		}
+
+		if recordComplianceHistory(policy) {
+			plcToUpdateMap[policy.Name] = policy
+			update = true
+		}
+	}
+
+	return update
+}
+
+// setUnknownComplianceOnFailure sets the compliance state to UnknownCompliancy when the policy couldn't be
+// fully evaluated for longer than the unknown compliance grace period, since its compliance state is stale.
+// The failure started when the EvaluationSucceeded condition became false. A policy with violations found
+// despite the evaluation errors of some of its roles stays non-compliant. It returns whether the compliance
+// state changed.
+func (e *Evaluator) setUnknownComplianceOnFailure(plc *iampolicyv1.IamPolicy, evalErr error, now time.Time) bool {
+	if plc.Status.ComplianceState == iampolicyv1.UnknownCompliancy {
+		return false
+	}
+
+	failed := evalErr != nil
+	violations := false
+
+	for _, evaluation := range plc.Status.Evaluations {
+		if len(evaluation.EvaluationErrors) != 0 {
+			failed = true
+		}
+
+		if evaluation.Excess > 0 || len(evaluation.Violations) != 0 {
+			violations = true
+		}
+	}
+
+	// The evaluations are stale when evalErr is set
+	if !failed || (evalErr == nil && violations) {
+		return false
+	}
+
+	failedSince := now
+
+	evaluationSucceeded := meta.FindStatusCondition(plc.Status.Conditions, iampolicyv1.ConditionEvaluationSucceeded)
+	if evaluationSucceeded != nil && evaluationSucceeded.Status == metav1.ConditionFalse {
+		failedSince = evaluationSucceeded.LastTransitionTime.Time
+	}
+
+	if now.Sub(failedSince) < e.UnknownComplianceGracePeriod {
+		log.Info("Keeping the compliance state of the policy that couldn't be evaluated during the grace period",
+			"Name", plc.Name, "Namespace", plc.Namespace, "FailedSince", failedSince)
+
+		return false
+	}
+
+	plc.Status.ComplianceState = iampolicyv1.UnknownCompliancy
+
+	return true
+}
+
+// setStatusConditions sets the Compliant, EvaluationSucceeded, and Ready conditions and the observed
+// generation from the compliance state and the evaluations in the status. When evalErr is set, the policy
+// could not be evaluated, so the observed generation is left as is. The transition time of a condition is
+// only updated when its status changes. It returns whether the status changed.
+func setStatusConditions(plc *iampolicyv1.IamPolicy, evalErr error) (changed bool) {
+	evaluationErrors := []string{}
+
+	if evalErr != nil {
+		evaluationErrors = append(evaluationErrors, evalErr.Error())
+	} else if plc.Status.ObservedGeneration != plc.Generation {
+		plc.Status.ObservedGeneration = plc.Generation
+		changed = true
+	}
+
+	for _, evaluation := range plc.Status.Evaluations {
+		for _, evaluationErr := range evaluation.EvaluationErrors {
+			evaluationErrors = append(evaluationErrors, fmt.Sprintf(evaluationErrMsgF, evaluation.Role, evaluationErr))
+		}
+	}
+
+	compliant := metav1.Condition{
+		Type:    iampolicyv1.ConditionCompliant,
+		Status:  metav1.ConditionUnknown,
+		Reason:  string(iampolicyv1.UnknownCompliancy),
+		Message: "The compliance is not determined yet",
+	}
+
+	switch plc.Status.ComplianceState {
+	case iampolicyv1.Compliant:
+		compliant.Status = metav1.ConditionTrue
+		compliant.Reason = string(iampolicyv1.Compliant)
+		compliant.Message = "No violations were found"
+	case iampolicyv1.NonCompliant:
+		compliant.Status = metav1.ConditionFalse
+		compliant.Reason = string(iampolicyv1.NonCompliant)
+		compliant.Message = strings.Join(getViolationMessages(plc), "; ")
+	case iampolicyv1.UnknownCompliancy:
+		// The Unknown status set above applies, with the errors or the unresolved groups that caused it
+		if len(evaluationErrors) != 0 {
+			compliant.Message = "The compliance can't be determined: " + strings.Join(evaluationErrors, "; ")
+		} else if unresolvedGroups := getAllUnresolvedGroups(plc); len(unresolvedGroups) != 0 {
+			compliant.Message = "The users of the groups " + strings.Join(unresolvedGroups, ", ") +
+				" can't be resolved"
+		}
+	}
+
+	evaluationSucceeded := metav1.Condition{
+		Type:    iampolicyv1.ConditionEvaluationSucceeded,
+		Status:  metav1.ConditionTrue,
+		Reason:  "EvaluationSucceeded",
+		Message: "The policy was evaluated successfully",
+	}
+
+	ready := metav1.Condition{
+		Type:    iampolicyv1.ConditionReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Evaluated",
+		Message: "The policy was evaluated and its compliance is determined",
+	}
+
+	if len(evaluationErrors) != 0 {
+		evaluationSucceeded.Status = metav1.ConditionFalse
+		evaluationSucceeded.Reason = "EvaluationError"
+		evaluationSucceeded.Message = strings.Join(evaluationErrors, "; ")
+
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = "EvaluationError"
+		ready.Message = "The policy could not be fully evaluated"
+	} else if compliant.Status == metav1.ConditionUnknown {
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = "EvaluationPending"
+		ready.Message = "The compliance of the policy is not determined yet"
+	}
+
+	for _, condition := range []metav1.Condition{compliant, evaluationSucceeded, ready} {
+		condition.ObservedGeneration = plc.Status.ObservedGeneration
+
+		if setStatusCondition(&plc.Status.Conditions, condition) {
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// getAllUnresolvedGroups returns the sorted names of the unresolved groups of all the evaluations.
+func getAllUnresolvedGroups(plc *iampolicyv1.IamPolicy) []string {
+	var unresolvedGroups []string
+
+	for _, evaluation := range plc.Status.Evaluations {
+		for _, group := range evaluation.UnresolvedGroups {
+			if !slices.Contains(unresolvedGroups, group) {
+				unresolvedGroups = append(unresolvedGroups, group)
+			}
+		}
+	}
+
+	sort.Strings(unresolvedGroups)
+
+	return unresolvedGroups
+}
+
+// setStatusCondition sets the condition in the conditions if it differs from the existing condition of the
+// same type, and returns true if it was set. The transition time is only updated when the status changes.
+func setStatusCondition(conditions *[]metav1.Condition, condition metav1.Condition) bool {
+	existing := meta.FindStatusCondition(*conditions, condition.Type)
+	if existing != nil && existing.Status == condition.Status && existing.Reason == condition.Reason &&
+		existing.Message == condition.Message && existing.ObservedGeneration == condition.ObservedGeneration {
+		return false
+	}
+
+	meta.SetStatusCondition(conditions, condition)
+
+	return true
+}
+
+// recordComplianceHistory adds an entry to the compliance history when the compliance state differs from
+// the latest entry, and trims the history to the complianceHistoryLimit of the policy. The message of the
+// entry is the message of the Compliant condition. It returns whether the history changed.
+func recordComplianceHistory(plc *iampolicyv1.IamPolicy) (changed bool) {
+	limit := defaultComplianceHistoryLimit
+	if plc.Spec.ComplianceHistoryLimit != nil {
+		limit = *plc.Spec.ComplianceHistoryLimit
+	}
+
+	history := plc.Status.ComplianceHistory
+	state := plc.Status.ComplianceState
+
+	var previousState iampolicyv1.ComplianceState
+	if len(history) != 0 {
+		previousState = history[0].State
+	}
+
+	if state != "" && state != previousState && limit > 0 {
+		entry := iampolicyv1.ComplianceHistoryEntry{
+			Timestamp:     metav1.Now(),
+			PreviousState: previousState,
+			State:         state,
+		}
+
+		for _, evaluation := range plc.Status.Evaluations {
+			entry.SubjectCount += evaluation.SubjectCount
+		}
+
+		compliant := meta.FindStatusCondition(plc.Status.Conditions, iampolicyv1.ConditionCompliant)
+		if compliant != nil {
+			entry.Message = compliant.Message
+		}
+
+		history = append([]iampolicyv1.ComplianceHistoryEntry{entry}, history...)
+		changed = true
+	}
+
+	if len(history) > limit {
+		history = history[:limit]
+		changed = true
+	}
+
+	if len(history) == 0 {
+		history = nil
+	}
+
+	plc.Status.ComplianceHistory = history
+
+	return changed
+}
+
+// getViolationMessages returns the messages of the evaluations in the status that make the policy
+// non-compliant, each prefixed with the scope of the evaluation.
+func getViolationMessages(plc *iampolicyv1.IamPolicy) []string {
+	messages := []string{}
+
+	for _, evaluation := range plc.Status.Evaluations {
+		if evaluation.Excess > 0 {
+			msg := fmt.Sprintf(violationMsgF, evaluation.Role, fmt.Sprint(evaluation.Excess))
+			messages = append(messages, evaluation.Scope+": "+msg)
+		}
+
+		for _, violation := range evaluation.Violations {
+			messages = append(messages, evaluation.Scope+": "+violation)
+		}
+	}
+
+	return messages
+}
+
+// updatePolicyStatus updates the status of the policies and records their events. A failure to update a policy
+// doesn't prevent the other policies from being updated, and the errors are returned together.
+func (e *Evaluator) updatePolicyStatus(policies map[string]*iampolicyv1.IamPolicy) error {
+	log.Info("Updating status for IAM Policies")
+
+	var errs []error
+
+	for _, instance := range policies { // policies is a map where: key = plc.Name, value = pointer to plc
+		err := e.updateStatus(instance)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to update the status of the IamPolicy %s/%s: %w",
+				instance.Namespace, instance.Name, err))
+
+			continue
+		}
+
+		if !strings.EqualFold(e.EventOnParent, "no") && e.Recorder != nil {
+			e.createParentPolicyEvent(instance)
+		}
+
+		// Can we make this eventing enabled by a flag
+		if e.Recorder != nil {
+			if instance.Status.ComplianceState == iampolicyv1.NonCompliant {
+				e.Recorder.Event(instance, corev1.EventTypeWarning,
+					fmt.Sprintf(formatString, instance.Namespace, instance.Name),
+					convertPolicyStatusToString(instance))
+			} else {
+				e.Recorder.Event(instance, corev1.EventTypeNormal,
+					fmt.Sprintf(formatString, instance.Namespace, instance.Name),
+					convertPolicyStatusToString(instance))
+			}
+		}
+
+		log.Info("Status update complete", "IAMPolicy", instance.Name)
+	}
+
+	return stderrors.Join(errs...)
+}
+
+// updateStatus updates the status of the policy. The policy evaluated by the loop may be older than the policy
+// on the cluster, for example when a reconcile replaced it with an outdated cached copy, so the update is
+// retried on conflicts with the resource version of the latest policy.
+func (e *Evaluator) updateStatus(instance *iampolicyv1.IamPolicy) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		err := e.Client.Status().Update(context.TODO(), instance)
+		if !errors.IsConflict(err) {
+			return err
+		}
+
+		latest := &iampolicyv1.IamPolicy{}
+
+		if getErr := e.Client.Get(context.TODO(), client.ObjectKeyFromObject(instance), latest); getErr != nil {
+			return getErr
+		}
+
+		instance.ResourceVersion = latest.ResourceVersion
+
+		return err
+	})
+}
+
+func getContainerID(pod corev1.Pod, containerName string) string {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
 		if containerStatus.Name == containerName {
 			return containerStatus.ContainerID
 		}
@@ -511,17 +2424,16 @@ func getContainerID(pod corev1.Pod, containerName string) string {
 	return ""
 }
 
-func handleRemovingPolicy(name string, namespace string) {
-	for k, v := range availablePolicies.PolicyMap {
-		if v.Name == name && v.Namespace == namespace {
-			availablePolicies.RemoveObject(k)
-		}
-	}
+func (e *Evaluator) handleRemovingPolicy(name string, namespace string) {
+	// The policies are keyed by their namespace and name, like in handleAddingPolicy
+	e.availablePolicies.RemoveObject(fmt.Sprintf("%s.%s", namespace, name))
 }
 
-func handleAddingPolicy(plc *iampolicyv1.IamPolicy) {
+func (e *Evaluator) handleAddingPolicy(plc *iampolicyv1.IamPolicy) {
+	key := fmt.Sprintf("%s.%s", plc.Namespace, plc.Name)
+
 	// Since this policy isn't namespace based it will ignore namespace selection so the cluster is always checked
-	availablePolicies.AddObject(fmt.Sprintf("%s.%s", plc.Namespace, plc.Name), plc)
+	e.availablePolicies.AddObject(key, plc)
 }
 
 // =================================================================
@@ -540,7 +2452,7 @@ func printMap(myMap map[string]*iampolicyv1.IamPolicy) {
 	}
 }
 
-func createParentPolicyEvent(instance *iampolicyv1.IamPolicy) {
+func (e *Evaluator) createParentPolicyEvent(instance *iampolicyv1.IamPolicy) {
 	if len(instance.OwnerReferences) == 0 {
 		return // there is nothing to do, since no owner is set
 	}
@@ -552,10 +2464,10 @@ func createParentPolicyEvent(instance *iampolicyv1.IamPolicy) {
 	parentPlc := createParentPolicy(instance)
 
 	if instance.Status.ComplianceState == iampolicyv1.NonCompliant {
-		reconcilingAgent.Recorder.Event(&parentPlc, corev1.EventTypeWarning,
+		e.Recorder.Event(&parentPlc, corev1.EventTypeWarning,
 			fmt.Sprintf(formatString, instance.Namespace, instance.Name), convertPolicyStatusToString(instance))
 	} else {
-		reconcilingAgent.Recorder.Event(&parentPlc, corev1.EventTypeNormal,
+		e.Recorder.Event(&parentPlc, corev1.EventTypeNormal,
 			fmt.Sprintf(formatString, instance.Namespace, instance.Name), convertPolicyStatusToString(instance))
 	}
 }
@@ -10,12 +10,16 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	coretypes "k8s.io/api/core/v1"
 	sub "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -24,10 +28,12 @@ import (
 	"k8s.io/client-go/kubernetes"
 	testclient "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	iampolicyv1 "open-cluster-management.io/iam-policy-controller/api/v1"
+	"open-cluster-management.io/iam-policy-controller/pkg/groups"
 )
 
 var iamPolicy = iampolicyv1.IamPolicy{
@@ -37,6 +43,19 @@ var iamPolicy = iampolicyv1.IamPolicy{
 	},
 }
 
+// newTestEvaluator returns an Evaluator of the target cluster clients which evaluates the input policies.
+func newTestEvaluator(
+	targetClient kubernetes.Interface, targetDynamicClient dynamic.Interface, policies ...*iampolicyv1.IamPolicy,
+) *Evaluator {
+	evaluator := NewEvaluator(nil, nil, targetClient, targetDynamicClient, "")
+
+	for _, policy := range policies {
+		evaluator.handleAddingPolicy(policy)
+	}
+
+	return evaluator
+}
+
 func TestReconcile(t *testing.T) {
 	var (
 		name      = "foo"
@@ -57,12 +76,17 @@ func TestReconcile(t *testing.T) {
 	objs := []runtime.Object{instance}
 	// Register operator types with the runtime scheme.
 	runtimeScheme := scheme.Scheme
-	runtimeScheme.AddKnownTypes(iampolicyv1.GroupVersion, instance)
+	runtimeScheme.AddKnownTypes(
+		iampolicyv1.GroupVersion, instance, &iampolicyv1.IamPolicyException{}, &iampolicyv1.IamPolicyExceptionList{},
+	)
 
 	// Create a fake client to mock API calls.
 	cl := fake.NewClientBuilder().WithRuntimeObjects(objs...).Build()
+	var simpleClient kubernetes.Interface = testclient.NewSimpleClientset()
 	// Create a ReconcileIamPolicy object with the scheme and fake client
-	reconcileIamPolicy := &IamPolicyReconciler{Client: cl, Scheme: runtimeScheme, Recorder: nil}
+	reconcileIamPolicy := &IamPolicyReconciler{
+		Client: cl, Scheme: runtimeScheme, Evaluator: newTestEvaluator(simpleClient, nil),
+	}
 
 	// Mock request to simulate Reconcile() being called on an event for a
 	// watched resource .
@@ -72,9 +96,6 @@ func TestReconcile(t *testing.T) {
 			Namespace: namespace,
 		},
 	}
-	var simpleClient kubernetes.Interface = testclient.NewSimpleClientset()
-
-	Initialize(&simpleClient, nil, "")
 
 	res, err := reconcileIamPolicy.Reconcile(context.TODO(), req)
 	if err != nil {
@@ -124,13 +145,13 @@ func TestPeriodicallyExecIamPolicies(t *testing.T) {
 	objs := []runtime.Object{instance}
 	// Register operator types with the runtime scheme.
 	runtimeScheme := scheme.Scheme
-	runtimeScheme.AddKnownTypes(iampolicyv1.GroupVersion, instance)
+	runtimeScheme.AddKnownTypes(
+		iampolicyv1.GroupVersion, instance, &iampolicyv1.IamPolicyException{}, &iampolicyv1.IamPolicyExceptionList{},
+	)
 
 	// Create a fake client to mock API calls.
 	cl := fake.NewClientBuilder().WithRuntimeObjects(objs...).Build()
 
-	// Create a ReconcileIamPolicy object with the scheme and fake client.
-	reconcileIamPolicy := &IamPolicyReconciler{Client: cl, Scheme: runtimeScheme, Recorder: nil}
 	var simpleClient kubernetes.Interface = testclient.NewSimpleClientset()
 
 	_, err := simpleClient.CoreV1().Namespaces().Create(context.TODO(), &nameSpace, metav1.CreateOptions{})
@@ -138,7 +159,11 @@ func TestPeriodicallyExecIamPolicies(t *testing.T) {
 		panic(err)
 	}
 
-	Initialize(&simpleClient, nil, "")
+	evaluator := newTestEvaluator(simpleClient, nil)
+	evaluator.Client = cl
+
+	// Create a ReconcileIamPolicy object with the scheme and fake client.
+	reconcileIamPolicy := &IamPolicyReconciler{Client: cl, Scheme: runtimeScheme, Evaluator: evaluator}
 
 	res, err := reconcileIamPolicy.Reconcile(context.TODO(), req)
 	if err != nil {
@@ -149,17 +174,53 @@ func TestPeriodicallyExecIamPolicies(t *testing.T) {
 
 	target := []iampolicyv1.NonEmptyString{"default"}
 	iamPolicy.Spec.NamespaceSelector.Include = target
-	handleAddingPolicy(&iamPolicy)
+	evaluator.handleAddingPolicy(&iamPolicy)
+
+	// Run a single loop by canceling the context beforehand
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	evaluator.PeriodicallyExecIamPolicies(ctx, 1)
+}
+
+func TestEvaluatorStart(t *testing.T) {
+	var simpleClient kubernetes.Interface = testclient.NewSimpleClientset()
+
+	evaluator := newTestEvaluator(simpleClient, nil)
+	assert.True(t, evaluator.NeedLeaderElection())
+
+	// The loop is healthy when it isn't running, such as on the replicas which are not the leader
+	assert.Nil(t, evaluator.HealthCheck(nil))
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	stopped := make(chan error)
+
+	go func() { stopped <- evaluator.Start(ctx) }()
+
+	assert.Eventually(t, func() bool { return evaluator.loopDeadline.Load() != nil }, time.Second, time.Millisecond)
+	assert.Nil(t, evaluator.HealthCheck(nil))
 
-	exitExecLoop = "true"
+	// The loop is unhealthy once it didn't make progress by its deadline
+	deadline := time.Now().Add(-time.Second)
+	evaluator.loopDeadline.Store(&deadline)
+	assert.NotNil(t, evaluator.HealthCheck(nil))
 
-	PeriodicallyExecIamPolicies(1)
+	cancel()
+
+	select {
+	case err := <-stopped:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the evaluation loop to stop when the context is canceled")
+	}
+
+	assert.Nil(t, evaluator.HealthCheck(nil))
 }
 
 func TestCheckUnNamespacedPolicies(t *testing.T) {
 	var simpleClient kubernetes.Interface = testclient.NewSimpleClientset()
 
-	Initialize(&simpleClient, nil, "")
+	evaluator := newTestEvaluator(simpleClient, nil)
 
 	iamPolicy := iampolicyv1.IamPolicy{
 		ObjectMeta: metav1.ObjectMeta{
@@ -171,7 +232,7 @@ func TestCheckUnNamespacedPolicies(t *testing.T) {
 	policies := map[string]*iampolicyv1.IamPolicy{}
 	policies["policy1"] = &iamPolicy
 
-	_, err := checkUnNamespacedPolicies(policies)
+	_, err := evaluator.checkUnNamespacedPolicies(policies)
 	assert.Nil(t, err)
 }
 
@@ -195,27 +256,19 @@ func TestGetGroupMembership(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		// Restore targetK8sDynamicClient after the test
-		oldDynamicClient := targetK8sDynamicClient
-		defer func() { targetK8sDynamicClient = oldDynamicClient }()
-
 		// Register the OpenShift Group type with the runtime scheme
 		runtimeScheme := scheme.Scheme
 		runtimeScheme.AddKnownTypes(groupGV, &group{})
 		var client dynamic.Interface = testdynamicclient.NewSimpleDynamicClient(runtimeScheme, &test.group)
-		targetK8sDynamicClient = &client
+		evaluator := newTestEvaluator(nil, client)
 
-		users, err := getGroupMembership(test.group.Name)
+		users, err := evaluator.getGroupMembership(test.group.Name)
 		assert.Nil(t, err)
 		assert.Equal(t, test.expectedUsers, users)
 	}
 }
 
 func TestCheckAllClusterLevel(t *testing.T) {
-	// Restore targetK8sDynamicClient after the test
-	oldDynamicClient := targetK8sDynamicClient
-	defer func() { targetK8sDynamicClient = oldDynamicClient }()
-
 	// Register the OpenShift Group type with the runtime scheme
 	runtimeScheme := scheme.Scheme
 	runtimeScheme.AddKnownTypes(groupGV, &group{})
@@ -322,7 +375,7 @@ func TestCheckAllClusterLevel(t *testing.T) {
 				var client dynamic.Interface = testdynamicclient.NewSimpleDynamicClient(
 					runtimeScheme, &groupObj,
 				)
-				targetK8sDynamicClient = &client
+				evaluator := newTestEvaluator(nil, client)
 
 				userSubject := sub.Subject{
 					APIGroup:  "",
@@ -352,8 +405,8 @@ func TestCheckAllClusterLevel(t *testing.T) {
 					Items: items,
 				}
 
-				users, err := checkAllClusterLevel(
-					&clusterRoleBindingList, "cluster-admin", test.ignoreCRBs,
+				_, users, _, err := evaluator.checkAllClusterLevel(
+					&clusterRoleBindingList, []string{"cluster-admin"}, test.ignoreCRBs, nil, false, nil, 0, "",
 				)
 
 				assert.Nil(t, err)
@@ -379,7 +432,7 @@ func TestCreateParentPolicy(t *testing.T) {
 
 	policy := createParentPolicy(&iamPolicy)
 	assert.NotNil(t, policy)
-	createParentPolicyEvent(&iamPolicy)
+	newTestEvaluator(nil, nil).createParentPolicyEvent(&iamPolicy)
 }
 
 func TestHandleAddingPolicy(t *testing.T) {
@@ -425,7 +478,7 @@ func TestHandleAddingPolicy(t *testing.T) {
 		panic(err)
 	}
 
-	Initialize(&simpleClient, nil, "")
+	evaluator := newTestEvaluator(simpleClient, nil)
 
 	for _, test := range tests {
 		test := test
@@ -436,15 +489,15 @@ func TestHandleAddingPolicy(t *testing.T) {
 				t.Parallel()
 				iamPolicy := iamPolicy
 				iamPolicy.Spec.NamespaceSelector.Include = []iampolicyv1.NonEmptyString{test.namespaceSelector}
-				handleAddingPolicy(&iamPolicy)
-				policy, found := availablePolicies.GetObject(iamPolicy.Namespace + "." + iamPolicy.Name)
+				evaluator.handleAddingPolicy(&iamPolicy)
+				policy, found := evaluator.availablePolicies.GetObject(iamPolicy.Namespace + "." + iamPolicy.Name)
 				assert.True(t, found)
 				assert.NotNil(t, policy)
 				assert.Equal(t, test.complianceState, policy.Status.ComplianceState)
 				assert.Equal(t, test.expectedMsg, policy.Status.CompliancyDetails["foo"]["cluster-wide"][0])
-				handleRemovingPolicy("foo", "default")
+				evaluator.handleRemovingPolicy("foo", "default")
 
-				policy, found = availablePolicies.GetObject(iamPolicy.Namespace + "." + iamPolicy.Name)
+				policy, found = evaluator.availablePolicies.GetObject(iamPolicy.Namespace + "." + iamPolicy.Name)
 				assert.False(t, found)
 				assert.Nil(t, policy)
 			},
@@ -474,68 +527,57 @@ func TestGetContainerID(_ *testing.T) {
 	getContainerID(pod, "foo")
 }
 
-func TestAddViolationCount(t *testing.T) {
+func TestSetRoleEvaluation(t *testing.T) {
+	countMsg := "The number of users with the cluster-admin role is at least 5 above the specified limit"
+	evaluation := iampolicyv1.RoleEvaluation{
+		Scope: "cluster-wide", Role: "cluster-admin", SubjectCount: 6, Limit: 1, Excess: 5,
+	}
+
 	tests := []struct {
 		compliancyDetails map[string]iampolicyv1.CompliancyDetail
-		userCount         int
-		roleName          string
-		expectedMsg       string
+		evaluations       []iampolicyv1.RoleEvaluation
+		evaluation        iampolicyv1.RoleEvaluation
+		expectedMsgs      []string
 		expectedChange    bool
 	}{
+		{nil, nil, evaluation, []string{countMsg}, true},
+		{map[string]iampolicyv1.CompliancyDetail{}, nil, evaluation, []string{countMsg}, true},
+		{map[string]iampolicyv1.CompliancyDetail{"foo": {}}, nil, evaluation, []string{countMsg}, true},
 		{
+			map[string]iampolicyv1.CompliancyDetail{"foo": {"cluster-wide": {}}},
 			nil,
-			5,
-			"cluster-admin",
-			"The number of users with the cluster-admin role is at least 5 above the specified limit",
-			true,
-		},
-		{
-			map[string]iampolicyv1.CompliancyDetail{},
-			5,
-			"cluster-admin",
-			"The number of users with the cluster-admin role is at least 5 above the specified limit",
-			true,
-		},
-		{
-			nil,
-			3,
-			"cluster-admin",
-			"The number of users with the cluster-admin role is at least 3 above the specified limit",
+			evaluation,
+			[]string{countMsg},
 			true,
 		},
 		{
+			map[string]iampolicyv1.CompliancyDetail{"foo": {"cluster-wide": {countMsg}}},
 			nil,
-			5,
-			"admins",
-			"The number of users with the admins role is at least 5 above the specified limit",
-			true,
-		},
-		{
-			map[string]iampolicyv1.CompliancyDetail{"foo": {}},
-			5,
-			"cluster-admin",
-			"The number of users with the cluster-admin role is at least 5 above the specified limit",
+			evaluation,
+			[]string{countMsg},
 			true,
 		},
 		{
-			map[string]iampolicyv1.CompliancyDetail{"foo": {"cluster-wide": {}}},
-			5,
-			"cluster-admin",
-			"The number of users with the cluster-admin role is at least 5 above the specified limit",
-			true,
+			map[string]iampolicyv1.CompliancyDetail{"foo": {"cluster-wide": {countMsg}}},
+			[]iampolicyv1.RoleEvaluation{evaluation},
+			evaluation,
+			[]string{countMsg},
+			false,
 		},
 		{
-			map[string]iampolicyv1.CompliancyDetail{
-				"foo": {
-					"cluster-wide": {
-						"The number of users with the cluster-admin role is at least 5 above the specified limit",
-					},
-				},
+			map[string]iampolicyv1.CompliancyDetail{"foo": {"cluster-wide": {countMsg}}},
+			[]iampolicyv1.RoleEvaluation{evaluation},
+			iampolicyv1.RoleEvaluation{
+				Scope: "cluster-wide", Role: "cluster-admin", SubjectCount: 1, Limit: 1,
+				Violations:       []string{"The users with the cluster-admin role that are not allowed are: user1"},
+				EvaluationErrors: []string{"error parsing regexp"},
 			},
-			5,
-			"cluster-admin",
-			"The number of users with the cluster-admin role is at least 5 above the specified limit",
-			false,
+			[]string{
+				"The number of users with the cluster-admin role is at least 0 above the specified limit",
+				"The users with the cluster-admin role that are not allowed are: user1",
+				"The cluster-admin role could not be fully evaluated: error parsing regexp",
+			},
+			true,
 		},
 	}
 
@@ -550,12 +592,1494 @@ func TestAddViolationCount(t *testing.T) {
 			},
 			Status: iampolicyv1.IamPolicyStatus{
 				CompliancyDetails: test.compliancyDetails,
+				Evaluations:       test.evaluations,
 			},
 		}
 
-		changed := addViolationCount(policy, test.roleName, test.userCount, "cluster-wide")
+		changed := setRoleEvaluation(policy, test.evaluation)
 
 		assert.Equal(t, test.expectedChange, changed)
-		assert.Equal(t, test.expectedMsg, policy.Status.CompliancyDetails["foo"]["cluster-wide"][0])
+		assert.Equal(t, test.expectedMsgs, policy.Status.CompliancyDetails["foo"]["cluster-wide"])
+		assert.Equal(t, []iampolicyv1.RoleEvaluation{test.evaluation}, policy.Status.Evaluations)
+	}
+}
+
+func TestRemoveExcessSubjects(t *testing.T) {
+	runtimeScheme := scheme.Scheme
+	runtimeScheme.AddKnownTypes(groupGV, &group{})
+
+	groupObj := group{ObjectMeta: metav1.ObjectMeta{Name: "admins"}, Users: []string{"tom.hanks", "user1"}}
+
+	var dynamicClient dynamic.Interface = testdynamicclient.NewSimpleDynamicClient(runtimeScheme, &groupObj)
+
+	older := sub.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "older",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+		},
+		Subjects: []sub.Subject{
+			{Kind: "User", Name: "user1"},
+			{Kind: "Group", Name: "admins"},
+		},
+		RoleRef: sub.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+	}
+	newer := sub.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "newer",
+			CreationTimestamp: metav1.NewTime(time.Now()),
+		},
+		Subjects: []sub.Subject{
+			{Kind: "User", Name: "han.solo"},
+			{Kind: "ServiceAccount", Name: "default", Namespace: "default"},
+			{Kind: "User", Name: "luke.skywalker"},
+		},
+		RoleRef: sub.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+	}
+
+	tests := []struct {
+		maxUsers         int
+		expectedRemoved  []string
+		expectedOlder    []string
+		expectedNewer    []string
+		expectedNewCount int
+	}{
+		{4, []string{}, []string{"user1", "admins"}, []string{"han.solo", "default", "luke.skywalker"}, 4},
+		{3, []string{"luke.skywalker"}, []string{"user1", "admins"}, []string{"han.solo", "default"}, 3},
+		{2, []string{"luke.skywalker", "han.solo"}, []string{"user1", "admins"}, []string{"default"}, 2},
+		// Removing the admins group only removes tom.hanks since user1 is also bound directly.
+		{1, []string{"luke.skywalker", "han.solo", "admins"}, []string{"user1"}, []string{"default"}, 1},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(fmt.Sprintf("maxUsers=%d", test.maxUsers), func(t *testing.T) {
+			var simpleClient kubernetes.Interface = testclient.NewSimpleClientset(older.DeepCopy(), newer.DeepCopy())
+
+			evaluator := newTestEvaluator(simpleClient, dynamicClient)
+
+			clusterRoleBindingList := sub.ClusterRoleBindingList{
+				Items: []sub.ClusterRoleBinding{*older.DeepCopy(), *newer.DeepCopy()},
+			}
+
+			grants, _, _, err := evaluator.checkAllClusterLevel(
+				&clusterRoleBindingList, []string{"cluster-admin"}, nil, nil, false, nil, 0, "",
+			)
+			assert.Nil(t, err)
+
+			removed, err := evaluator.removeExcessSubjects(grants, test.maxUsers)
+			assert.Nil(t, err)
+
+			removedNames := []string{}
+			for _, subject := range removed {
+				removedNames = append(removedNames, subject.Name)
+			}
+
+			assert.Equal(t, test.expectedRemoved, removedNames)
+
+			for name, expected := range map[string][]string{"older": test.expectedOlder, "newer": test.expectedNewer} {
+				crb, err := simpleClient.RbacV1().ClusterRoleBindings().Get(context.TODO(), name, metav1.GetOptions{})
+				assert.Nil(t, err)
+
+				subjects := []string{}
+				for _, subject := range crb.Subjects {
+					subjects = append(subjects, subject.Name)
+				}
+
+				assert.Equal(t, expected, subjects)
+			}
+
+			_, count, _, err := evaluator.checkAllClusterLevel(
+				&clusterRoleBindingList, []string{"cluster-admin"}, nil, nil, false, nil, 0, "",
+			)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expectedNewCount, count)
+		})
+	}
+}
+
+func TestRemoveSubjectsOfUsersInSeveralBindings(t *testing.T) {
+	newBinding := func(name string, age time.Duration, subjects ...sub.Subject) sub.ClusterRoleBinding {
+		return sub.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(time.Now().Add(-age))},
+			Subjects:   subjects,
+			RoleRef:    sub.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+		}
+	}
+
+	alice := sub.Subject{Kind: "User", Name: "alice"}
+
+	tests := map[string]struct {
+		bindings        []sub.ClusterRoleBinding
+		maxUsers        int
+		expectedRemoved []string
+		expectedCount   int
+	}{
+		// Removing alice from the newer binding first wouldn't lower the number of users
+		"the user bound once is removed": {
+			bindings: []sub.ClusterRoleBinding{
+				newBinding("older", time.Hour, alice, sub.Subject{Kind: "User", Name: "bob"}),
+				newBinding("newer", 0, sub.Subject{Kind: "User", Name: "carol"}, alice),
+			},
+			maxUsers:        2,
+			expectedRemoved: []string{"newer/carol"},
+			expectedCount:   2,
+		},
+		"the user bound several times is removed from every binding": {
+			bindings: []sub.ClusterRoleBinding{
+				newBinding("older", time.Hour, alice, sub.Subject{Kind: "Group", Name: "oidc:ops"}),
+				newBinding("newer", 0, alice),
+			},
+			maxUsers:        1,
+			expectedRemoved: []string{"newer/alice", "older/alice"},
+			expectedCount:   1,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			var simpleClient kubernetes.Interface = testclient.NewSimpleClientset(
+				test.bindings[0].DeepCopy(), test.bindings[1].DeepCopy(),
+			)
+
+			evaluator := newTestEvaluator(simpleClient, nil)
+			evaluator.GroupResolver = fakeResolver{}
+
+			clusterRoleBindingList := sub.ClusterRoleBindingList{Items: test.bindings}
+
+			// The unresolved group is counted as one user, but it is never removed
+			grants, _, _, err := evaluator.checkAllClusterLevel(
+				&clusterRoleBindingList, []string{"cluster-admin"}, nil, nil, false, nil, 0, iampolicyv1.CountAsOne,
+			)
+			assert.Nil(t, err)
+
+			removed, err := evaluator.removeExcessSubjects(grants, test.maxUsers)
+			assert.Nil(t, err)
+
+			removedNames := []string{}
+			for _, subject := range removed {
+				removedNames = append(removedNames, subject.ClusterRoleBinding+"/"+subject.Name)
+			}
+
+			assert.Equal(t, test.expectedRemoved, removedNames)
+
+			_, count, _, err := evaluator.checkAllClusterLevel(
+				&clusterRoleBindingList, []string{"cluster-admin"}, nil, nil, false, nil, 0, iampolicyv1.CountAsOne,
+			)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expectedCount, count)
+		})
+	}
+}
+
+func TestEnforceWithoutLimit(t *testing.T) {
+	admins := &sub.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+		Subjects:   []sub.Subject{{Kind: "User", Name: "user1"}, {Kind: "User", Name: "user2"}},
+		RoleRef:    sub.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+	}
+
+	var simpleClient kubernetes.Interface = testclient.NewSimpleClientset(admins.DeepCopy())
+
+	evaluator := newTestEvaluator(simpleClient, nil)
+
+	// The omitted maxClusterRoleBindingUsers is 0, which must not remove every subject
+	policy := &iampolicyv1.IamPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Spec:       iampolicyv1.IamPolicySpec{RemediationAction: iampolicyv1.Enforce},
+	}
+	clusterRoleBindingList := &sub.ClusterRoleBindingList{Items: []sub.ClusterRoleBinding{*admins.DeepCopy()}}
+
+	changed, removed := evaluator.checkClusterRole(
+		policy, getRoleLimits(policy, nil)[0], clusterRoleBindingList, nil,
+	)
+	assert.True(t, changed)
+	assert.Empty(t, removed)
+
+	crb, err := simpleClient.RbacV1().ClusterRoleBindings().Get(context.TODO(), "admins", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, crb.Subjects, 2)
+
+	assert.Contains(
+		t,
+		policy.Status.Evaluations[0].Violations,
+		"The subjects bound to the cluster-admin role are not removed since the policy doesn't set a maximum "+
+			"number of users",
+	)
+
+	checkComplianceBasedOnEvaluations(policy)
+	assert.Equal(t, iampolicyv1.NonCompliant, policy.Status.ComplianceState)
+}
+
+func TestCheckNamespacedPolicies(t *testing.T) {
+	roleBinding := func(namespace, name, role string, users ...string) *sub.RoleBinding {
+		subjects := []sub.Subject{}
+		for _, user := range users {
+			subjects = append(subjects, sub.Subject{Kind: "User", Name: user})
+		}
+
+		return &sub.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Subjects:   subjects,
+			RoleRef:    sub.RoleRef{Kind: "ClusterRole", Name: role},
+		}
+	}
+
+	var simpleClient kubernetes.Interface = testclient.NewSimpleClientset(
+		&coretypes.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app1"}},
+		&coretypes.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app2"}},
+		&coretypes.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		roleBinding("app1", "admins", "cluster-admin", "user1", "user2", "user3"),
+		roleBinding("app1", "editors", "edit", "user4"),
+		roleBinding("app2", "admins", "cluster-admin", "user1"),
+		roleBinding("kube-system", "admins", "cluster-admin", "user1", "user2", "user3"),
+	)
+
+	evaluator := newTestEvaluator(simpleClient, nil)
+
+	policy := &iampolicyv1.IamPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "namespaced", Namespace: "default"},
+		Spec: iampolicyv1.IamPolicySpec{
+			MaxClusterRoleBindingUsers: 2,
+			NamespaceSelector: iampolicyv1.Target{
+				Include: []iampolicyv1.NonEmptyString{"*"},
+				Exclude: []iampolicyv1.NonEmptyString{"kube-*"},
+			},
+		},
+		Status: iampolicyv1.IamPolicyStatus{
+			CompliancyDetails: map[string]iampolicyv1.CompliancyDetail{
+				"namespaced": {
+					"cluster-wide": {
+						"The number of users with the cluster-admin role is at least 0 above the specified limit",
+					},
+					"deleted-namespace": {
+						"The number of users with the cluster-admin role is at least 5 above the specified limit",
+					},
+				},
+			},
+		},
+	}
+
+	evaluator.handleAddingPolicy(policy)
+
+	plcToUpdateMap := map[string]*iampolicyv1.IamPolicy{}
+
+	update, err := evaluator.checkNamespacedPolicies(plcToUpdateMap)
+	assert.Nil(t, err)
+	assert.True(t, update)
+	assert.Contains(t, plcToUpdateMap, "namespaced")
+	assert.Equal(t, iampolicyv1.NonCompliant, policy.Status.ComplianceState)
+	assert.Equal(
+		t,
+		iampolicyv1.CompliancyDetail{
+			"cluster-wide": {
+				"The number of users with the cluster-admin role is at least 0 above the specified limit",
+			},
+			"app1": {"The number of users with the cluster-admin role is at least 1 above the specified limit"},
+			"app2": {"The number of users with the cluster-admin role is at least 0 above the specified limit"},
+		},
+		policy.Status.CompliancyDetails["namespaced"],
+	)
+
+	// Clearing the namespace selector removes the namespace details
+	policy.Spec.NamespaceSelector = iampolicyv1.Target{}
+
+	update, err = evaluator.checkNamespacedPolicies(plcToUpdateMap)
+	assert.Nil(t, err)
+	assert.True(t, update)
+	assert.Equal(t, iampolicyv1.Compliant, policy.Status.ComplianceState)
+	assert.Equal(
+		t,
+		iampolicyv1.CompliancyDetail{
+			"cluster-wide": {
+				"The number of users with the cluster-admin role is at least 0 above the specified limit",
+			},
+		},
+		policy.Status.CompliancyDetails["namespaced"],
+	)
+}
+
+func TestCheckAllClusterLevelServiceAccounts(t *testing.T) {
+	clusterRoleBindingList := sub.ClusterRoleBindingList{
+		Items: []sub.ClusterRoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "automation"},
+				Subjects: []sub.Subject{
+					{Kind: "ServiceAccount", Name: "deployer", Namespace: "ci"},
+					{Kind: "ServiceAccount", Name: "pruner", Namespace: "ci"},
+					{Kind: "User", Name: "system:serviceaccount:ci:deployer"},
+					{Kind: "User", Name: "user1"},
+				},
+				RoleRef: sub.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+			},
+		},
+	}
+
+	evaluator := newTestEvaluator(nil, nil)
+
+	_, users, _, err := evaluator.checkAllClusterLevel(
+		&clusterRoleBindingList, []string{"cluster-admin"}, nil, nil, false, nil, 0, "",
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, users)
+
+	// The deployer ServiceAccount and User subjects are the same identity
+	grants, users, _, err := evaluator.checkAllClusterLevel(
+		&clusterRoleBindingList, []string{"cluster-admin"}, nil, nil, true, nil, 0, "",
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, users)
+	assert.Equal(t, []string{"system:serviceaccount:ci:deployer"}, grants[0].users)
+
+	roleBindings := []sub.RoleBinding{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "automation", Namespace: "app"},
+			Subjects:   []sub.Subject{{Kind: "ServiceAccount", Name: "default"}},
+			RoleRef:    sub.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+		},
+	}
+
+	grants, users, _, err = evaluator.checkNamespaceLevel(
+		roleBindings, []string{"cluster-admin"}, nil, nil, true, nil, "",
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, users)
+	assert.Equal(t, []string{"system:serviceaccount:app:default"}, grants[0].users)
+}
+
+func TestCheckAllClusterLevelIgnoreSubjects(t *testing.T) {
+	runtimeScheme := scheme.Scheme
+	runtimeScheme.AddKnownTypes(groupGV, &group{})
+
+	admins := group{ObjectMeta: metav1.ObjectMeta{Name: "admins"}, Users: []string{"user1", "break-glass-1"}}
+	operators := group{ObjectMeta: metav1.ObjectMeta{Name: "operators"}, Users: []string{"user2", "user3"}}
+
+	var dynamicClient dynamic.Interface = testdynamicclient.NewSimpleDynamicClient(
+		runtimeScheme, &admins, &operators,
+	)
+	evaluator := newTestEvaluator(nil, dynamicClient)
+
+	clusterRoleBindingList := sub.ClusterRoleBindingList{
+		Items: []sub.ClusterRoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+				Subjects: []sub.Subject{
+					{Kind: "User", Name: "break-glass-1"},
+					{Kind: "User", Name: "break-glass-2"},
+					{Kind: "User", Name: "user4"},
+					{Kind: "Group", Name: "admins"},
+					{Kind: "Group", Name: "operators"},
+					{Kind: "ServiceAccount", Name: "installer", Namespace: "openshift-operators"},
+					{Kind: "ServiceAccount", Name: "deployer", Namespace: "ci"},
+				},
+				RoleRef: sub.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+			},
+		},
+	}
+
+	ignoreSubjects := []iampolicyv1.IgnoredSubject{
+		{Kind: "User", Name: "^break-glass-"},
+		{Kind: "Group", Name: "^operators$"},
+		{Kind: "ServiceAccount", Name: "^openshift-.+:"},
+	}
+
+	grants, users, ignored, err := evaluator.checkAllClusterLevel(
+		&clusterRoleBindingList, []string{"cluster-admin"}, nil, ignoreSubjects, true, nil, 0, "",
+	)
+	assert.Nil(t, err)
+	// user4, user1 from the admins group, and the ci:deployer ServiceAccount are counted
+	assert.Equal(t, 3, users)
+	// break-glass-1 is ignored both as a subject and as a member of the admins group
+	assert.Equal(t, 4, ignored)
+	assert.Len(t, grants, 3)
+	assert.Equal(t, []string{"user1"}, grants[1].users)
+
+	_, _, _, err = evaluator.checkAllClusterLevel(
+		&clusterRoleBindingList,
+		[]string{"cluster-admin"},
+		nil,
+		[]iampolicyv1.IgnoredSubject{{Kind: "User", Name: "("}},
+		true,
+		nil,
+		0,
+		"",
+	)
+	assert.NotNil(t, err)
+}
+
+func TestApprovedUntilBindings(t *testing.T) {
+	expiredAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+
+	newBinding := func(name string, approvedUntil string, users ...string) sub.ClusterRoleBinding {
+		binding := sub.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			RoleRef:    sub.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+		}
+
+		if approvedUntil != "" {
+			binding.Annotations = map[string]string{approvedUntilAnnotation: approvedUntil}
+		}
+
+		for _, user := range users {
+			binding.Subjects = append(binding.Subjects, sub.Subject{Kind: "User", Name: user})
+		}
+
+		return binding
+	}
+
+	bindings := []sub.ClusterRoleBinding{
+		newBinding("admins", "", "user1"),
+		newBinding("on-call", time.Now().Add(time.Hour).Format(time.RFC3339), "on-call-engineer", "user1"),
+		newBinding("incident", expiredAt.Format(time.RFC3339), "incident-engineer"),
+		newBinding("invalid", "tomorrow", "user2"),
+		newBinding("forever", "9999-12-31T00:00:00Z", "user3"),
+	}
+
+	var simpleClient kubernetes.Interface = testclient.NewSimpleClientset(
+		bindings[0].DeepCopy(), bindings[1].DeepCopy(), bindings[2].DeepCopy(), bindings[3].DeepCopy(),
+		bindings[4].DeepCopy(),
+	)
+	var dynamicClient dynamic.Interface = testdynamicclient.NewSimpleDynamicClient(scheme.Scheme)
+
+	evaluator := newTestEvaluator(simpleClient, dynamicClient)
+
+	clusterRoleBindingList := sub.ClusterRoleBindingList{Items: bindings}
+
+	// The annotations are ignored unless the policy allows approvals
+	grants, users, _, err := evaluator.checkAllClusterLevel(
+		&clusterRoleBindingList, []string{"cluster-admin"}, nil, nil, false, nil, 0, "",
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, users)
+	assert.Empty(t, getApprovedUsers(grants))
+	assert.False(t, hasExpiredApproval(grants))
+
+	grants, users, _, err = evaluator.checkAllClusterLevel(
+		&clusterRoleBindingList, []string{"cluster-admin"}, nil, nil, false, nil, 24*time.Hour, "",
+	)
+	assert.Nil(t, err)
+	// The on-call-engineer is approved, user1 is also bound by the admins binding, and the invalid annotation
+	// and the annotation beyond the maximum approval duration are ignored
+	assert.Equal(t, 4, users)
+	assert.Equal(t, []string{"on-call-engineer"}, getApprovedUsers(grants))
+	assert.True(t, hasExpiredApproval(grants))
+
+	spec := iampolicyv1.IamPolicySpec{AllowedUsers: []iampolicyv1.NonEmptyString{"user1", "user2"}}
+
+	violations, err := getSubjectViolations(grants, "cluster-admin", &spec)
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		[]string{
+			"The users with the cluster-admin role that are not allowed are: incident-engineer, user3",
+			fmt.Sprintf(violationMsgFExpired, "User incident-engineer", "cluster-admin", "incident",
+				expiredAt.Format(time.RFC3339)),
+		},
+		violations,
+	)
+
+	// The expired subject is removed even though the limit is met, and the approved subject is kept
+	removed, err := evaluator.removeExcessSubjects(grants, 5)
+	assert.Nil(t, err)
+	assert.Len(t, removed, 1)
+	assert.Equal(t, "incident-engineer", removed[0].Name)
+
+	grants, users, _, err = evaluator.checkAllClusterLevel(
+		&clusterRoleBindingList, []string{"cluster-admin"}, nil, nil, false, nil, 24*time.Hour, "",
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, users)
+	assert.False(t, hasExpiredApproval(grants))
+
+	// The approved subjects are not removed to meet the limit
+	removed, err = evaluator.removeExcessSubjects(grants, 0)
+	assert.Nil(t, err)
+
+	removedNames := []string{}
+	for _, subject := range removed {
+		removedNames = append(removedNames, subject.Name)
+	}
+
+	assert.ElementsMatch(t, []string{"user1", "user2", "user3"}, removedNames)
+
+	onCall, err := simpleClient.RbacV1().ClusterRoleBindings().Get(context.TODO(), "on-call", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, onCall.Subjects, 2)
+}
+
+func TestRemoveExpiredUnresolvedGroups(t *testing.T) {
+	expiredAt := time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+	bindings := []sub.ClusterRoleBinding{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+			RoleRef:    sub.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+			Subjects:   []sub.Subject{{Kind: "User", Name: "user1"}, {Kind: "Group", Name: "ldap:ops"}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "incident",
+				Annotations: map[string]string{approvedUntilAnnotation: expiredAt},
+			},
+			RoleRef:  sub.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+			Subjects: []sub.Subject{{Kind: "Group", Name: "oidc:responders"}},
+		},
 	}
+
+	var simpleClient kubernetes.Interface = testclient.NewSimpleClientset(
+		bindings[0].DeepCopy(), bindings[1].DeepCopy(),
+	)
+
+	evaluator := newTestEvaluator(simpleClient, nil)
+	evaluator.GroupResolver = fakeResolver{}
+
+	grants, _, _, err := evaluator.checkAllClusterLevel(
+		&sub.ClusterRoleBindingList{Items: bindings},
+		[]string{"cluster-admin"},
+		nil,
+		nil,
+		false,
+		nil,
+		iampolicyv1.DefaultMaxApprovalDuration,
+		"",
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ldap:ops", "oidc:responders"}, getUnresolvedGroups(grants))
+
+	// The unresolved group whose approval expired is removed, unlike the other unresolved group
+	removed, err := evaluator.removeExcessSubjects(grants, 0)
+	assert.Nil(t, err)
+
+	removedNames := []string{}
+	for _, subject := range removed {
+		removedNames = append(removedNames, subject.Name)
+	}
+
+	assert.Equal(t, []string{"oidc:responders", "user1"}, removedNames)
+
+	admins, err := simpleClient.RbacV1().ClusterRoleBindings().Get(context.TODO(), "admins", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []sub.Subject{{Kind: "Group", Name: "ldap:ops"}}, admins.Subjects)
+}
+
+func TestGetSubjectViolations(t *testing.T) {
+	grants := []roleGrant{
+		{subject: sub.Subject{Kind: "User", Name: "user1"}, users: []string{"user1"}},
+		{subject: sub.Subject{Kind: "User", Name: "user2"}, users: []string{"user2"}},
+		{subject: sub.Subject{Kind: "Group", Name: "admins"}, users: []string{"user2", "user3"}},
+		{
+			subject: sub.Subject{Kind: "ServiceAccount", Name: "deployer", Namespace: "ci"},
+			users:   []string{"system:serviceaccount:ci:deployer"},
+		},
+	}
+
+	tests := []struct {
+		description string
+		spec        iampolicyv1.IamPolicySpec
+		expected    []string
+	}{
+		{"No allowed lists", iampolicyv1.IamPolicySpec{}, []string{}},
+		{
+			"Only allowed users",
+			iampolicyv1.IamPolicySpec{AllowedUsers: []iampolicyv1.NonEmptyString{"user1", "user2"}},
+			[]string{
+				"The users with the cluster-admin role that are not allowed are: " +
+					"system:serviceaccount:ci:deployer, user3",
+			},
+		},
+		{
+			"Allowed group approves its members",
+			iampolicyv1.IamPolicySpec{
+				AllowedUsers:           []iampolicyv1.NonEmptyString{"user1"},
+				AllowedGroups:          []iampolicyv1.NonEmptyString{"admins"},
+				AllowedServiceAccounts: []iampolicyv1.NonEmptyString{"ci:deployer"},
+			},
+			[]string{},
+		},
+		{
+			"Only allowed groups",
+			iampolicyv1.IamPolicySpec{AllowedGroups: []iampolicyv1.NonEmptyString{"admins"}},
+			[]string{
+				"The users with the cluster-admin role that are not allowed are: " +
+					"system:serviceaccount:ci:deployer, user1",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.description, func(t *testing.T) {
+			violations, err := getSubjectViolations(grants, "cluster-admin", &test.spec)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, violations)
+		})
+	}
+}
+
+func TestCheckComplianceBasedOnEvaluations(t *testing.T) {
+	policy := &iampolicyv1.IamPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+	}
+
+	assert.True(t, checkComplianceBasedOnEvaluations(policy))
+	assert.Equal(t, iampolicyv1.Compliant, policy.Status.ComplianceState)
+
+	policy.Status.Evaluations = []iampolicyv1.RoleEvaluation{
+		{Scope: "cluster-wide", Role: "cluster-admin", SubjectCount: 1, Limit: 1},
+		{
+			Scope: "default", Role: "cluster-admin", SubjectCount: 1, Limit: 1,
+			Violations: []string{"The users with the cluster-admin role that are not allowed are: user1"},
+		},
+	}
+
+	assert.True(t, checkComplianceBasedOnEvaluations(policy))
+	assert.Equal(t, iampolicyv1.NonCompliant, policy.Status.ComplianceState)
+
+	// The compliance state is kept when the results are incomplete
+	policy.Status.Evaluations[1].Violations = nil
+	policy.Status.Evaluations[1].EvaluationErrors = []string{"error parsing regexp"}
+
+	assert.False(t, checkComplianceBasedOnEvaluations(policy))
+	assert.Equal(t, iampolicyv1.NonCompliant, policy.Status.ComplianceState)
+
+	policy.Status.Evaluations[1].EvaluationErrors = nil
+
+	assert.True(t, checkComplianceBasedOnEvaluations(policy))
+	assert.Equal(t, iampolicyv1.Compliant, policy.Status.ComplianceState)
+
+	// Users above the limit are a violation even with evaluation errors
+	policy.Status.Evaluations[0].SubjectCount = 2
+	policy.Status.Evaluations[0].Excess = 1
+	policy.Status.Evaluations[1].EvaluationErrors = []string{"error parsing regexp"}
+
+	assert.True(t, checkComplianceBasedOnEvaluations(policy))
+	assert.Equal(t, iampolicyv1.NonCompliant, policy.Status.ComplianceState)
+}
+
+func TestGetForbiddenViolations(t *testing.T) {
+	grants := []roleGrant{
+		{bindingName: "crb1", subject: sub.Subject{Kind: "User", Name: "user1"}, users: []string{"user1"}},
+		{
+			bindingName: "crb2",
+			subject:     sub.Subject{Kind: "Group", Name: "vendor-admins"},
+			users:       []string{"vendor-bob", "user2"},
+		},
+		{
+			bindingName: "crb3",
+			subject:     sub.Subject{Kind: "ServiceAccount", Name: "deployer", Namespace: "ci"},
+			users:       []string{"system:serviceaccount:ci:deployer"},
+		},
+	}
+
+	tests := []struct {
+		forbidden   []iampolicyv1.NonEmptyString
+		expected    []string
+		expectedErr bool
+	}{
+		{nil, []string{}, false},
+		{[]iampolicyv1.NonEmptyString{"^nobody$"}, []string{}, false},
+		{
+			[]iampolicyv1.NonEmptyString{"^user1$", "^system:serviceaccount:ci:.*"},
+			[]string{
+				"The User user1 matches the forbidden subject '^user1$' and is granted the cluster-admin role " +
+					"by the binding crb1",
+				"The ServiceAccount system:serviceaccount:ci:deployer matches the forbidden subject " +
+					"'^system:serviceaccount:ci:.*' and is granted the cluster-admin role by the binding crb3",
+			},
+			false,
+		},
+		{
+			[]iampolicyv1.NonEmptyString{"^vendor-"},
+			[]string{
+				"The Group vendor-admins matches the forbidden subject '^vendor-' and is granted the " +
+					"cluster-admin role by the binding crb2",
+				"The User vendor-bob from the Group vendor-admins matches the forbidden subject '^vendor-' and " +
+					"is granted the cluster-admin role by the binding crb2",
+			},
+			false,
+		},
+		{[]iampolicyv1.NonEmptyString{"(user"}, nil, true},
+	}
+
+	for _, test := range tests {
+		violations, err := getForbiddenViolations(grants, "cluster-admin", test.forbidden)
+
+		assert.Equal(t, test.expectedErr, err != nil)
+		assert.Equal(t, test.expected, violations)
+	}
+}
+
+func TestCheckUnNamespacedPoliciesClusterRoles(t *testing.T) {
+	clusterRoleBinding := func(name, role string, users ...string) *sub.ClusterRoleBinding {
+		subjects := []sub.Subject{}
+		for _, user := range users {
+			subjects = append(subjects, sub.Subject{Kind: "User", Name: user})
+		}
+
+		return &sub.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Subjects:   subjects,
+			RoleRef:    sub.RoleRef{Kind: "ClusterRole", Name: role},
+		}
+	}
+
+	var simpleClient kubernetes.Interface = testclient.NewSimpleClientset(
+		clusterRoleBinding("admins", "cluster-admin", "user1", "user2"),
+		clusterRoleBinding("namespace-admins", "admin", "user3"),
+		clusterRoleBinding("super-users", "super-user", "user4", "user5", "user6"),
+	)
+
+	evaluator := newTestEvaluator(simpleClient, nil)
+
+	policy := &iampolicyv1.IamPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "roles", Namespace: "default"},
+		Spec: iampolicyv1.IamPolicySpec{
+			MaxClusterRoleBindingUsers: 1,
+			ClusterRoles: []iampolicyv1.ClusterRoleLimit{
+				{Name: "cluster-admin", MaxUsers: 2},
+				{Name: "admin", MaxUsers: 1},
+				{Name: "super-user", MaxUsers: 1},
+			},
+		},
+		Status: iampolicyv1.IamPolicyStatus{
+			CompliancyDetails: map[string]iampolicyv1.CompliancyDetail{
+				"roles": {
+					"cluster-wide": {
+						"The number of users with the cluster-admin role is at least 1 above the specified limit",
+					},
+				},
+			},
+		},
+	}
+
+	evaluator.handleAddingPolicy(policy)
+
+	plcToUpdateMap := map[string]*iampolicyv1.IamPolicy{}
+
+	update, err := evaluator.checkUnNamespacedPolicies(plcToUpdateMap)
+	assert.Nil(t, err)
+	assert.True(t, update)
+	assert.Equal(t, iampolicyv1.NonCompliant, policy.Status.ComplianceState)
+	assert.Equal(
+		t,
+		iampolicyv1.CompliancyDetail{
+			"cluster-wide/cluster-admin": {
+				"The number of users with the cluster-admin role is at least 0 above the specified limit",
+			},
+			"cluster-wide/admin": {
+				"The number of users with the admin role is at least 0 above the specified limit",
+			},
+			"cluster-wide/super-user": {
+				"The number of users with the super-user role is at least 2 above the specified limit",
+			},
+		},
+		policy.Status.CompliancyDetails["roles"],
+	)
+
+	policy.Spec.ClusterRoles[2].MaxUsers = 3
+
+	update, err = evaluator.checkUnNamespacedPolicies(plcToUpdateMap)
+	assert.Nil(t, err)
+	assert.True(t, update)
+	assert.Equal(t, iampolicyv1.Compliant, policy.Status.ComplianceState)
+}
+
+func TestCheckUnNamespacedPoliciesPrivilegedRules(t *testing.T) {
+	var simpleClient kubernetes.Interface = testclient.NewSimpleClientset(
+		&sub.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"},
+			Rules:      []sub.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}},
+		},
+		&sub.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "secret-reader"},
+			Rules: []sub.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"list"}},
+			},
+		},
+		&sub.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "view"},
+			Rules: []sub.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"list"}},
+			},
+		},
+		&sub.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+			Subjects:   []sub.Subject{{Kind: "User", Name: "user1"}},
+			RoleRef:    sub.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+		},
+		&sub.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "secret-readers"},
+			Subjects:   []sub.Subject{{Kind: "User", Name: "user1"}, {Kind: "User", Name: "user2"}},
+			RoleRef:    sub.RoleRef{Kind: "ClusterRole", Name: "secret-reader"},
+		},
+		&sub.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "viewers"},
+			Subjects:   []sub.Subject{{Kind: "User", Name: "user3"}},
+			RoleRef:    sub.RoleRef{Kind: "ClusterRole", Name: "view"},
+		},
+	)
+
+	evaluator := newTestEvaluator(simpleClient, nil)
+
+	policy := &iampolicyv1.IamPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "privileged", Namespace: "default"},
+		Spec: iampolicyv1.IamPolicySpec{
+			ClusterRole:                "view",
+			MaxClusterRoleBindingUsers: 1,
+			PrivilegedRules: []iampolicyv1.PrivilegedRule{
+				{Resources: []string{"secrets"}, Verbs: []string{"list"}},
+			},
+		},
+	}
+
+	evaluator.handleAddingPolicy(policy)
+
+	update, err := evaluator.checkUnNamespacedPolicies(map[string]*iampolicyv1.IamPolicy{})
+	assert.Nil(t, err)
+	assert.True(t, update)
+	assert.Equal(t, iampolicyv1.NonCompliant, policy.Status.ComplianceState)
+	assert.Equal(
+		t,
+		iampolicyv1.CompliancyDetail{
+			"cluster-wide": {
+				"The number of users with the privileged role is at least 1 above the specified limit",
+			},
+		},
+		policy.Status.CompliancyDetails["privileged"],
+	)
+	assert.Equal(
+		t,
+		[]iampolicyv1.RoleEvaluation{
+			{Scope: "cluster-wide", Role: "privileged", SubjectCount: 2, Limit: 1, Excess: 1},
+		},
+		policy.Status.Evaluations,
+	)
+
+	policy.Spec.MaxClusterRoleBindingUsers = 2
+
+	update, err = evaluator.checkUnNamespacedPolicies(map[string]*iampolicyv1.IamPolicy{})
+	assert.Nil(t, err)
+	assert.True(t, update)
+	assert.Equal(t, iampolicyv1.Compliant, policy.Status.ComplianceState)
+}
+
+func TestFilterClusterRoleBindings(t *testing.T) {
+	clusterRoleBindingList := &sub.ClusterRoleBindingList{
+		Items: []sub.ClusterRoleBinding{
+			{ObjectMeta: metav1.ObjectMeta{Name: "platform", Labels: map[string]string{"owner": "platform"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "tenant1", Labels: map[string]string{"owner": "tenant1"}}},
+			{ObjectMeta: metav1.ObjectMeta{
+				Name: "tenant2", Labels: map[string]string{"owner": "tenant2", "env": "prod"},
+			}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "unlabeled"}},
+		},
+	}
+
+	tests := map[string]struct {
+		labelSelector map[string]string
+		expressions   []metav1.LabelSelectorRequirement
+		expected      []string
+		expectedErr   bool
+	}{
+		"no selector": {
+			nil, nil, []string{"platform", "tenant1", "tenant2", "unlabeled"}, false,
+		},
+		"labels": {
+			map[string]string{"owner": "tenant1"}, nil, []string{"tenant1"}, false,
+		},
+		"NotIn expression": {
+			nil,
+			[]metav1.LabelSelectorRequirement{
+				{Key: "owner", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"platform"}},
+			},
+			[]string{"tenant1", "tenant2", "unlabeled"},
+			false,
+		},
+		"labels and expressions": {
+			map[string]string{"env": "prod"},
+			[]metav1.LabelSelectorRequirement{
+				{Key: "owner", Operator: metav1.LabelSelectorOpIn, Values: []string{"tenant1", "tenant2"}},
+			},
+			[]string{"tenant2"},
+			false,
+		},
+		"invalid expression": {
+			nil,
+			[]metav1.LabelSelectorRequirement{{Key: "owner", Operator: metav1.LabelSelectorOpIn}},
+			nil,
+			true,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			filtered, err := filterClusterRoleBindings(clusterRoleBindingList, &iampolicyv1.IamPolicySpec{
+				LabelSelector:            test.labelSelector,
+				LabelSelectorExpressions: test.expressions,
+			})
+
+			assert.Equal(t, test.expectedErr, err != nil)
+
+			if test.expectedErr {
+				return
+			}
+
+			names := []string{}
+			for _, clusterRoleBinding := range filtered.Items {
+				names = append(names, clusterRoleBinding.Name)
+			}
+
+			assert.Equal(t, test.expected, names)
+		})
+	}
+}
+
+func TestSetStatusConditions(t *testing.T) {
+	policy := &iampolicyv1.IamPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default", Generation: 2},
+	}
+
+	getCondition := func(conditionType string) metav1.Condition {
+		condition := meta.FindStatusCondition(policy.Status.Conditions, conditionType)
+		if !assert.NotNil(t, condition) {
+			return metav1.Condition{}
+		}
+
+		return *condition
+	}
+
+	// The compliance is not determined before the first evaluation
+	assert.True(t, setStatusConditions(policy, nil))
+	assert.Equal(t, int64(2), policy.Status.ObservedGeneration)
+	assert.Equal(t, metav1.ConditionUnknown, getCondition(iampolicyv1.ConditionCompliant).Status)
+	assert.Equal(t, metav1.ConditionTrue, getCondition(iampolicyv1.ConditionEvaluationSucceeded).Status)
+	assert.Equal(t, metav1.ConditionFalse, getCondition(iampolicyv1.ConditionReady).Status)
+	assert.Equal(t, int64(2), getCondition(iampolicyv1.ConditionReady).ObservedGeneration)
+
+	policy.Status.ComplianceState = iampolicyv1.NonCompliant
+	policy.Status.Evaluations = []iampolicyv1.RoleEvaluation{
+		{Scope: "cluster-wide", Role: "cluster-admin", SubjectCount: 3, Limit: 1, Excess: 2},
+	}
+
+	assert.True(t, setStatusConditions(policy, nil))
+	assert.Equal(t, metav1.ConditionFalse, getCondition(iampolicyv1.ConditionCompliant).Status)
+	assert.Equal(
+		t,
+		"cluster-wide: The number of users with the cluster-admin role is at least 2 above the specified limit",
+		getCondition(iampolicyv1.ConditionCompliant).Message,
+	)
+	assert.Equal(t, metav1.ConditionTrue, getCondition(iampolicyv1.ConditionReady).Status)
+
+	// The transition time is kept when nothing changes
+	readyTime := metav1.NewTime(time.Now().Add(-time.Hour))
+	meta.FindStatusCondition(policy.Status.Conditions, iampolicyv1.ConditionReady).LastTransitionTime = readyTime
+
+	assert.False(t, setStatusConditions(policy, nil))
+	assert.Equal(t, readyTime, getCondition(iampolicyv1.ConditionReady).LastTransitionTime)
+
+	// The observed generation is kept when the policy could not be evaluated
+	policy.Generation = 3
+
+	assert.True(t, setStatusConditions(policy, errors.New("failed to list the ClusterRoles")))
+	assert.Equal(t, int64(2), policy.Status.ObservedGeneration)
+	assert.Equal(t, metav1.ConditionFalse, getCondition(iampolicyv1.ConditionEvaluationSucceeded).Status)
+	assert.Equal(
+		t, "failed to list the ClusterRoles", getCondition(iampolicyv1.ConditionEvaluationSucceeded).Message,
+	)
+	assert.Equal(t, metav1.ConditionFalse, getCondition(iampolicyv1.ConditionReady).Status)
+	assert.NotEqual(t, readyTime, getCondition(iampolicyv1.ConditionReady).LastTransitionTime)
+	assert.Equal(t, metav1.ConditionFalse, getCondition(iampolicyv1.ConditionCompliant).Status)
+}
+
+func TestRecordComplianceHistory(t *testing.T) {
+	limit := 2
+	policy := &iampolicyv1.IamPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Spec:       iampolicyv1.IamPolicySpec{ComplianceHistoryLimit: &limit},
+	}
+
+	// Nothing is recorded before the compliance is determined
+	assert.False(t, recordComplianceHistory(policy))
+	assert.Nil(t, policy.Status.ComplianceHistory)
+
+	policy.Status.ComplianceState = iampolicyv1.Compliant
+	policy.Status.Evaluations = []iampolicyv1.RoleEvaluation{
+		{Scope: "cluster-wide", Role: "cluster-admin", SubjectCount: 1, Limit: 1},
+	}
+
+	assert.True(t, setStatusConditions(policy, nil))
+	assert.True(t, recordComplianceHistory(policy))
+	assert.Len(t, policy.Status.ComplianceHistory, 1)
+	assert.Equal(t, iampolicyv1.ComplianceState(""), policy.Status.ComplianceHistory[0].PreviousState)
+	assert.Equal(t, iampolicyv1.Compliant, policy.Status.ComplianceHistory[0].State)
+	assert.Equal(t, 1, policy.Status.ComplianceHistory[0].SubjectCount)
+	assert.Equal(t, "No violations were found", policy.Status.ComplianceHistory[0].Message)
+
+	// Nothing is recorded when the compliance state doesn't change
+	assert.False(t, recordComplianceHistory(policy))
+	assert.Len(t, policy.Status.ComplianceHistory, 1)
+
+	policy.Status.ComplianceState = iampolicyv1.NonCompliant
+	policy.Status.Evaluations[0].SubjectCount = 3
+	policy.Status.Evaluations[0].Excess = 2
+
+	assert.True(t, setStatusConditions(policy, nil))
+	assert.True(t, recordComplianceHistory(policy))
+	assert.Len(t, policy.Status.ComplianceHistory, 2)
+	assert.Equal(t, iampolicyv1.Compliant, policy.Status.ComplianceHistory[0].PreviousState)
+	assert.Equal(t, iampolicyv1.NonCompliant, policy.Status.ComplianceHistory[0].State)
+	assert.Equal(t, 3, policy.Status.ComplianceHistory[0].SubjectCount)
+	assert.Equal(
+		t,
+		"cluster-wide: The number of users with the cluster-admin role is at least 2 above the specified limit",
+		policy.Status.ComplianceHistory[0].Message,
+	)
+
+	// The oldest entries are removed beyond the limit
+	policy.Status.ComplianceState = iampolicyv1.Compliant
+
+	assert.True(t, recordComplianceHistory(policy))
+	assert.Len(t, policy.Status.ComplianceHistory, 2)
+	assert.Equal(t, iampolicyv1.Compliant, policy.Status.ComplianceHistory[0].State)
+	assert.Equal(t, iampolicyv1.NonCompliant, policy.Status.ComplianceHistory[1].State)
+
+	limit = 0
+
+	assert.True(t, recordComplianceHistory(policy))
+	assert.Nil(t, policy.Status.ComplianceHistory)
+}
+
+func TestGetDuePolicies(t *testing.T) {
+	newPolicy := func(name string, state iampolicyv1.ComplianceState) *iampolicyv1.IamPolicy {
+		return &iampolicyv1.IamPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "managed", Generation: 1},
+			Spec: iampolicyv1.IamPolicySpec{
+				EvaluationInterval: iampolicyv1.EvaluationInterval{Compliant: "never", NonCompliant: "30s"},
+			},
+			Status: iampolicyv1.IamPolicyStatus{ComplianceState: state},
+		}
+	}
+
+	compliant := newPolicy("compliant", iampolicyv1.Compliant)
+	noncompliant := newPolicy("noncompliant", iampolicyv1.NonCompliant)
+	unknown := newPolicy("unknown", "")
+
+	evaluator := newTestEvaluator(nil, nil, compliant, noncompliant, unknown)
+
+	start := time.Now()
+
+	// All the policies are due before their first evaluation
+	due, next := evaluator.getDuePolicies(start, time.Minute)
+	assert.Equal(
+		t, map[string]bool{"managed.compliant": true, "managed.noncompliant": true, "managed.unknown": true}, due,
+	)
+	assert.Equal(t, start.Add(time.Minute), next)
+
+	evaluator.recordEvaluations(due, start)
+
+	due, next = evaluator.getDuePolicies(start.Add(10*time.Second), time.Minute)
+	assert.Empty(t, due)
+	assert.Equal(t, start.Add(30*time.Second), next)
+
+	// The compliant policy is never evaluated again and the unknown one uses the default interval
+	due, _ = evaluator.getDuePolicies(start.Add(40*time.Second), time.Minute)
+	assert.Equal(t, map[string]bool{"managed.noncompliant": true}, due)
+
+	due, _ = evaluator.getDuePolicies(start.Add(2*time.Hour), time.Minute)
+	assert.Equal(t, map[string]bool{"managed.noncompliant": true, "managed.unknown": true}, due)
+
+	// A spec change triggers an evaluation regardless of the interval
+	changed := compliant.DeepCopy()
+	changed.Generation = 2
+	evaluator.handleAddingPolicy(changed)
+
+	due, _ = evaluator.getDuePolicies(start.Add(10*time.Second), time.Minute)
+	assert.Equal(t, map[string]bool{"managed.compliant": true}, due)
+
+	// Only the due policies are checked and removed policies are forgotten
+	evaluator.duePolicies = due
+
+	checked := evaluator.convertMaptoPolicyNameKey()
+	assert.Len(t, checked, 1)
+	assert.Contains(t, checked, "managed.compliant")
+
+	evaluator.availablePolicies.RemoveObject("managed.unknown")
+	evaluator.recordEvaluations(due, start.Add(10*time.Second))
+
+	assert.Len(t, evaluator.lastEvaluations, 2)
+	assert.Equal(t, int64(2), evaluator.lastEvaluations["managed.compliant"].generation)
+}
+
+func TestPolicyExceptions(t *testing.T) {
+	newException := func(
+		name string, expires time.Time, binding string, kind string, subject string,
+	) iampolicyv1.IamPolicyException {
+		exception := iampolicyv1.IamPolicyException{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "managed"},
+			Spec: iampolicyv1.IamPolicyExceptionSpec{
+				PolicyName:         "policy",
+				ClusterRoleBinding: binding,
+				Justification:      "Needed for the migration",
+				Approver:           "security-team",
+				Expires:            metav1.NewTime(expires),
+			},
+		}
+
+		if kind != "" {
+			exception.Spec.Subject = &iampolicyv1.ExceptionSubject{
+				Kind: kind, Name: iampolicyv1.NonEmptyString(subject),
+			}
+		}
+
+		return exception
+	}
+
+	tomorrow := time.Now().Add(24 * time.Hour)
+	exceptions := []iampolicyv1.IamPolicyException{
+		newException("migration", tomorrow, "migration", "", ""),
+		newException("deployer", tomorrow, "", "ServiceAccount", "ci:deployer"),
+		newException("expired", time.Now().Add(-time.Hour), "", "User", "user2"),
+		newException("admins-user1", tomorrow, "admins", "User", "user1"),
+	}
+
+	clusterRoleBindingList := sub.ClusterRoleBindingList{
+		Items: []sub.ClusterRoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+				RoleRef:    sub.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+				Subjects: []sub.Subject{
+					{Kind: "User", Name: "user1"},
+					{Kind: "User", Name: "user2"},
+					{Kind: "ServiceAccount", Name: "deployer", Namespace: "ci"},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "migration"},
+				RoleRef:    sub.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+				Subjects:   []sub.Subject{{Kind: "User", Name: "user3"}, {Kind: "User", Name: "user1"}},
+			},
+		},
+	}
+
+	evaluator := newTestEvaluator(nil, nil)
+
+	grants, users, _, err := evaluator.checkAllClusterLevel(
+		&clusterRoleBindingList, []string{"cluster-admin"}, nil, nil, true, exceptions, 0, "",
+	)
+	assert.Nil(t, err)
+	// Only user2 is counted since its exception expired
+	assert.Equal(t, 1, users)
+	assert.Equal(t, 3, len(getApprovedUsers(grants)))
+	assert.Equal(t, []string{"admins-user1", "deployer", "migration"}, getAppliedExceptions(grants))
+
+	spec := iampolicyv1.IamPolicySpec{AllowedUsers: []iampolicyv1.NonEmptyString{"user2"}}
+
+	violations, err := getSubjectViolations(grants, "cluster-admin", &spec)
+	assert.Nil(t, err)
+	assert.Empty(t, violations)
+
+	// The excepted subjects are not forbidden either
+	spec = iampolicyv1.IamPolicySpec{ForbiddenSubjects: []iampolicyv1.NonEmptyString{"^user|deployer"}}
+
+	violations, err = getSubjectViolations(grants, "cluster-admin", &spec)
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		[]string{fmt.Sprintf(violationMsgFForbidden, "User user2", "^user|deployer", "cluster-admin", "admins")},
+		violations,
+	)
+
+	// The exceptions for cluster role bindings don't apply to role bindings
+	roleBindings := []sub.RoleBinding{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "admins", Namespace: "app"},
+			RoleRef:    sub.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+			Subjects: []sub.Subject{
+				{Kind: "User", Name: "user1"},
+				{Kind: "ServiceAccount", Name: "deployer", Namespace: "ci"},
+			},
+		},
+	}
+
+	grants, users, _, err = evaluator.checkNamespaceLevel(
+		roleBindings, []string{"cluster-admin"}, nil, nil, true, exceptions, "",
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, users)
+	assert.Equal(t, []string{"deployer"}, getAppliedExceptions(grants))
+}
+
+// fakeResolver resolves the groups in the map and returns groups.ErrGroupNotFound for the others
+type fakeResolver map[string][]string
+
+func (r fakeResolver) GetMembers(_ context.Context, group string) ([]string, error) {
+	if users, ok := r[group]; ok {
+		return users, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", groups.ErrGroupNotFound, group)
+}
+
+func TestUnresolvableGroups(t *testing.T) {
+	evaluator := newTestEvaluator(nil, nil)
+	evaluator.GroupResolver = fakeResolver{"admins": {"user1", "user2"}}
+
+	clusterRoleBindingList := sub.ClusterRoleBindingList{
+		Items: []sub.ClusterRoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+				RoleRef:    sub.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+				Subjects: []sub.Subject{
+					{Kind: "Group", Name: "admins"},
+					{Kind: "Group", Name: "oidc:admins"},
+					{Kind: "Group", Name: "oidc:admins"},
+					{Kind: "Group", Name: "ldap:ops"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		mode               iampolicyv1.UnresolvableGroupsMode
+		expectedUsers      int
+		expectedViolations int
+		expectedCompliance iampolicyv1.ComplianceState
+	}{
+		{iampolicyv1.CountAsOne, 4, 0, iampolicyv1.NonCompliant},
+		{iampolicyv1.CountAsUnlimited, 2, 1, iampolicyv1.NonCompliant},
+		{iampolicyv1.Unknown, 2, 0, iampolicyv1.UnknownCompliancy},
+		{iampolicyv1.Ignore, 2, 0, iampolicyv1.Compliant},
+		{"", 2, 0, iampolicyv1.Compliant},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(string(test.mode), func(t *testing.T) {
+			grants, users, _, err := evaluator.checkAllClusterLevel(
+				&clusterRoleBindingList, []string{"cluster-admin"}, nil, nil, false, nil, 0, test.mode,
+			)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expectedUsers, users)
+			assert.Equal(t, []string{"ldap:ops", "oidc:admins"}, getUnresolvedGroups(grants))
+
+			policy := &iampolicyv1.IamPolicy{
+				Spec: iampolicyv1.IamPolicySpec{MaxClusterRoleBindingUsers: 3, UnresolvableGroups: test.mode},
+			}
+			evaluation := iampolicyv1.RoleEvaluation{
+				Role:             "cluster-admin",
+				SubjectCount:     users,
+				Limit:            3,
+				UnresolvedGroups: getUnresolvedGroups(grants),
+			}
+
+			if users > evaluation.Limit {
+				evaluation.Excess = users - evaluation.Limit
+			}
+
+			setEvaluationViolations(&evaluation, grants, &policy.Spec)
+			assert.Len(t, evaluation.Violations, test.expectedViolations)
+
+			policy.Status.Evaluations = []iampolicyv1.RoleEvaluation{evaluation}
+			checkComplianceBasedOnEvaluations(policy)
+			assert.Equal(t, test.expectedCompliance, policy.Status.ComplianceState)
+		})
+	}
+}
+
+func TestEmptyGroupIsCompliant(t *testing.T) {
+	// An OpenShift Group without users has a null users field
+	runtimeScheme := scheme.Scheme
+	runtimeScheme.AddKnownTypes(groupGV, &group{})
+	var client dynamic.Interface = testdynamicclient.NewSimpleDynamicClient(
+		runtimeScheme, &group{ObjectMeta: metav1.ObjectMeta{Name: "empty"}, Users: nil},
+	)
+	evaluator := newTestEvaluator(nil, client)
+
+	clusterRoleBindingList := sub.ClusterRoleBindingList{
+		Items: []sub.ClusterRoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+				RoleRef:    sub.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+				Subjects: []sub.Subject{
+					{Kind: "User", Name: "user1"},
+					{Kind: "Group", Name: "empty"},
+				},
+			},
+		},
+	}
+
+	modes := []iampolicyv1.UnresolvableGroupsMode{
+		iampolicyv1.CountAsOne, iampolicyv1.CountAsUnlimited, iampolicyv1.Unknown, iampolicyv1.Ignore, "",
+	}
+
+	for _, mode := range modes {
+		mode := mode
+
+		t.Run(string(mode), func(t *testing.T) {
+			grants, users, _, err := evaluator.checkAllClusterLevel(
+				&clusterRoleBindingList, []string{"cluster-admin"}, nil, nil, false, nil, 0, mode,
+			)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, users)
+			assert.Empty(t, getUnresolvedGroups(grants))
+
+			policy := &iampolicyv1.IamPolicy{
+				Spec: iampolicyv1.IamPolicySpec{MaxClusterRoleBindingUsers: 1, UnresolvableGroups: mode},
+			}
+			evaluation := iampolicyv1.RoleEvaluation{
+				Role:             "cluster-admin",
+				SubjectCount:     users,
+				Limit:            1,
+				UnresolvedGroups: getUnresolvedGroups(grants),
+			}
+
+			setEvaluationViolations(&evaluation, grants, &policy.Spec)
+			assert.Empty(t, evaluation.Violations)
+
+			policy.Status.Evaluations = []iampolicyv1.RoleEvaluation{evaluation}
+			checkComplianceBasedOnEvaluations(policy)
+			assert.Equal(t, iampolicyv1.Compliant, policy.Status.ComplianceState)
+		})
+	}
+}
+
+func TestSetUnknownComplianceOnFailure(t *testing.T) {
+	evaluator := newTestEvaluator(nil, nil)
+	now := time.Now()
+	listErr := errors.New("failed to list the ClusterRoleBindings")
+
+	policy := &iampolicyv1.IamPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Status: iampolicyv1.IamPolicyStatus{
+			ComplianceState: iampolicyv1.Compliant,
+			Evaluations: []iampolicyv1.RoleEvaluation{
+				{Scope: "cluster-wide", Role: "cluster-admin", SubjectCount: 1, Limit: 1},
+			},
+		},
+	}
+
+	// A successful evaluation keeps its compliance state
+	assert.False(t, evaluator.setUnknownComplianceOnFailure(policy, nil, now))
+	assert.Equal(t, iampolicyv1.Compliant, policy.Status.ComplianceState)
+
+	// The stale state is kept during the grace period, which starts at the first failure
+	evaluator.UnknownComplianceGracePeriod = 5 * time.Minute
+
+	assert.False(t, evaluator.setUnknownComplianceOnFailure(policy, listErr, now))
+	assert.True(t, setStatusConditions(policy, listErr))
+
+	meta.FindStatusCondition(
+		policy.Status.Conditions, iampolicyv1.ConditionEvaluationSucceeded,
+	).LastTransitionTime = metav1.NewTime(now.Add(-time.Minute))
+
+	assert.False(t, evaluator.setUnknownComplianceOnFailure(policy, listErr, now))
+	assert.Equal(t, iampolicyv1.Compliant, policy.Status.ComplianceState)
+
+	// The compliance is unknown once the grace period elapsed
+	assert.True(t, evaluator.setUnknownComplianceOnFailure(policy, listErr, now.Add(5*time.Minute)))
+	assert.Equal(t, iampolicyv1.UnknownCompliancy, policy.Status.ComplianceState)
+
+	setStatusConditions(policy, listErr)
+
+	compliant := meta.FindStatusCondition(policy.Status.Conditions, iampolicyv1.ConditionCompliant)
+	assert.Equal(t, metav1.ConditionUnknown, compliant.Status)
+	assert.Equal(t, "The compliance can't be determined: failed to list the ClusterRoleBindings", compliant.Message)
+	assert.Equal(
+		t,
+		"UnknownCompliancy; The compliance can't be determined: failed to list the ClusterRoleBindings",
+		convertPolicyStatusToString(policy),
+	)
+
+	// The violations found despite the evaluation errors of other roles are not stale
+	evaluator.UnknownComplianceGracePeriod = 0
+	policy.Status.ComplianceState = iampolicyv1.NonCompliant
+	policy.Status.Evaluations = []iampolicyv1.RoleEvaluation{
+		{Scope: "cluster-wide", Role: "cluster-admin", SubjectCount: 3, Limit: 1, Excess: 2},
+		{Scope: "cluster-wide", Role: "admin", EvaluationErrors: []string{"invalid ignoreSubjects regex"}},
+	}
+
+	assert.False(t, evaluator.setUnknownComplianceOnFailure(policy, nil, now))
+	assert.Equal(t, iampolicyv1.NonCompliant, policy.Status.ComplianceState)
+
+	policy.Status.Evaluations = policy.Status.Evaluations[1:]
+
+	assert.True(t, evaluator.setUnknownComplianceOnFailure(policy, nil, now))
+	assert.Equal(t, iampolicyv1.UnknownCompliancy, policy.Status.ComplianceState)
+}
+
+func TestUpdatePolicyStatus(t *testing.T) {
+	runtimeScheme := runtime.NewScheme()
+	assert.Nil(t, iampolicyv1.AddToScheme(runtimeScheme))
+
+	policy := &iampolicyv1.IamPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Spec:       iampolicyv1.IamPolicySpec{MaxClusterRoleBindingUsers: 1},
+	}
+
+	cl := fake.NewClientBuilder().
+		WithScheme(runtimeScheme).
+		WithObjects(policy).
+		WithStatusSubresource(policy).
+		Build()
+
+	evaluator := newTestEvaluator(nil, nil)
+	evaluator.Client = cl
+
+	// The evaluated policy is outdated since the policy was changed after it was cached
+	stale := &iampolicyv1.IamPolicy{}
+	assert.Nil(t, cl.Get(context.TODO(), client.ObjectKeyFromObject(policy), stale))
+
+	latest := stale.DeepCopy()
+	latest.Spec.Severity = "high"
+	assert.Nil(t, cl.Update(context.TODO(), latest))
+
+	stale.Status.ComplianceState = iampolicyv1.Compliant
+
+	// The policy that no longer exists doesn't prevent the status of the other policy from being updated
+	missing := &iampolicyv1.IamPolicy{ObjectMeta: metav1.ObjectMeta{Name: "missing", Namespace: "default"}}
+	missing.Status.ComplianceState = iampolicyv1.NonCompliant
+
+	err := evaluator.updatePolicyStatus(map[string]*iampolicyv1.IamPolicy{"missing": missing, "foo": stale})
+	assert.True(t, k8serrors.IsNotFound(err))
+
+	updated := &iampolicyv1.IamPolicy{}
+	assert.Nil(t, cl.Get(context.TODO(), client.ObjectKeyFromObject(policy), updated))
+	assert.Equal(t, iampolicyv1.Compliant, updated.Status.ComplianceState)
+	assert.Equal(t, "high", updated.Spec.Severity)
+}
+
+func TestConcurrentPolicyChanges(t *testing.T) {
+	evaluator := newTestEvaluator(nil, nil)
+	done := make(chan struct{})
+
+	// The reconciles add and remove policies while the evaluation loop iterates over them
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 1000; i++ {
+			name := fmt.Sprintf("policy%d", i%10)
+
+			evaluator.handleAddingPolicy(&iampolicyv1.IamPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "managed"},
+			})
+			evaluator.handleRemovingPolicy(name, "managed")
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		due, _ := evaluator.getDuePolicies(time.Now(), time.Minute)
+		evaluator.duePolicies = due
+		evaluator.convertMaptoPolicyNameKey()
+		evaluator.recordEvaluations(due, time.Now())
+	}
+
+	<-done
+
+	assert.Empty(t, evaluator.getAvailablePolicies(func(*iampolicyv1.IamPolicy) bool { return true }))
 }
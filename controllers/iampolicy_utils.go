@@ -10,8 +10,15 @@ package controllers
 
 import (
 	"fmt"
+	"path/filepath"
+	"slices"
 	"strings"
 
+	v1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
 	iampolicyv1 "open-cluster-management.io/iam-policy-controller/api/v1"
 )
 
@@ -25,6 +32,16 @@ func convertPolicyStatusToString(plc *iampolicyv1.IamPolicy) (results string) {
 
 	result = string(plc.Status.ComplianceState)
 
+	// The details are stale when the compliance is unknown, so the reason from the condition is reported
+	if plc.Status.ComplianceState == iampolicyv1.UnknownCompliancy {
+		compliant := meta.FindStatusCondition(plc.Status.Conditions, iampolicyv1.ConditionCompliant)
+		if compliant != nil {
+			result += "; " + compliant.Message
+		}
+
+		return result
+	}
+
 	if plc.Status.CompliancyDetails == nil {
 		return result
 	}
@@ -39,3 +56,173 @@ func convertPolicyStatusToString(plc *iampolicyv1.IamPolicy) (results string) {
 
 	return result
 }
+
+// getSelectedNamespaces returns the namespaces that match an include pattern and don't match an exclude
+// pattern of the namespace selector. The patterns support the wildcards of filepath.Match, such as `*`.
+func getSelectedNamespaces(namespaces []string, selector iampolicyv1.Target) ([]string, error) {
+	selected := []string{}
+
+	for _, namespace := range namespaces {
+		included, err := matchesAnyPattern(namespace, selector.Include)
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespaceSelector include pattern: %w", err)
+		}
+
+		if !included {
+			continue
+		}
+
+		excluded, err := matchesAnyPattern(namespace, selector.Exclude)
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespaceSelector exclude pattern: %w", err)
+		}
+
+		if !excluded {
+			selected = append(selected, namespace)
+		}
+	}
+
+	return selected, nil
+}
+
+func matchesAnyPattern(name string, patterns []iampolicyv1.NonEmptyString) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := filepath.Match(string(pattern), name)
+		if err != nil {
+			return false, fmt.Errorf("'%s': %w", pattern, err)
+		}
+
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// getPrivilegedClusterRoles returns the names of the ClusterRoles that grant every permission of at least
+// one of the privileged rules.
+func getPrivilegedClusterRoles(clusterRoles []v1.ClusterRole, privilegedRules []iampolicyv1.PrivilegedRule) []string {
+	privileged := []string{}
+
+	for _, clusterRole := range clusterRoles {
+		for _, privilegedRule := range privilegedRules {
+			if grantsPrivilegedRule(clusterRole.Rules, privilegedRule) {
+				privileged = append(privileged, clusterRole.Name)
+
+				break
+			}
+		}
+	}
+
+	return privileged
+}
+
+// grantsPrivilegedRule returns true if the policy rules grant every verb on every resource in every API
+// group of the privileged rule. A `*` in the privileged rule is only granted by a `*` in the policy rules.
+// The policy rules limited to resourceNames are skipped since they only grant access to some objects of
+// the resources, and the nonResourceURLs rules are not matched since the privileged rules select resources.
+func grantsPrivilegedRule(rules []v1.PolicyRule, privilegedRule iampolicyv1.PrivilegedRule) bool {
+	apiGroups := privilegedRule.APIGroups
+	if len(apiGroups) == 0 {
+		apiGroups = []string{""}
+	}
+
+	for _, apiGroup := range apiGroups {
+		for _, resource := range privilegedRule.Resources {
+			for _, verb := range privilegedRule.Verbs {
+				granted := slices.ContainsFunc(rules, func(rule v1.PolicyRule) bool {
+					return len(rule.ResourceNames) == 0 && grantsValue(rule.APIGroups, apiGroup) &&
+						grantsValue(rule.Resources, resource) && grantsValue(rule.Verbs, verb)
+				})
+
+				if !granted {
+					return false
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+func grantsValue(values []string, value string) bool {
+	return slices.Contains(values, v1.ResourceAll) || slices.Contains(values, value)
+}
+
+// getAggregatedClusterRoles returns the name of the ClusterRole along with the names of the ClusterRoles
+// related to it through aggregation, in both directions. These are the ClusterRoles aggregated into it,
+// since their rules are part of it, and the ClusterRoles it is aggregated into, since they grant all of its
+// rules. Nested aggregations are followed in each direction.
+func getAggregatedClusterRoles(clusterRoles []v1.ClusterRole, name string) []string {
+	aggregated := []string{name}
+
+	var target *v1.ClusterRole
+
+	for i := range clusterRoles {
+		if clusterRoles[i].Name == name {
+			target = &clusterRoles[i]
+
+			break
+		}
+	}
+
+	if target == nil {
+		return aggregated
+	}
+
+	// Each direction is followed separately since a ClusterRole that a component is also aggregated into
+	// doesn't grant the rules of the target ClusterRole.
+	for _, upward := range []bool{false, true} {
+		visited := map[string]bool{name: true}
+		pending := []*v1.ClusterRole{target}
+
+		for len(pending) != 0 {
+			current := pending[0]
+			pending = pending[1:]
+
+			for i := range clusterRoles {
+				related := &clusterRoles[i]
+				if visited[related.Name] {
+					continue
+				}
+
+				if (upward && aggregates(related, current)) || (!upward && aggregates(current, related)) {
+					visited[related.Name] = true
+					pending = append(pending, related)
+
+					if !slices.Contains(aggregated, related.Name) {
+						aggregated = append(aggregated, related.Name)
+					}
+				}
+			}
+		}
+	}
+
+	return aggregated
+}
+
+// aggregates returns true if the aggregation rule of the aggregate ClusterRole selects the component
+// ClusterRole.
+func aggregates(aggregate *v1.ClusterRole, component *v1.ClusterRole) bool {
+	if aggregate.AggregationRule == nil {
+		return false
+	}
+
+	for _, clusterRoleSelector := range aggregate.AggregationRule.ClusterRoleSelectors {
+		clusterRoleSelector := clusterRoleSelector
+
+		selector, err := metav1.LabelSelectorAsSelector(&clusterRoleSelector)
+		if err != nil {
+			log.Error(err, "Invalid aggregation rule", "ClusterRole", aggregate.Name)
+
+			continue
+		}
+
+		if selector.Matches(labels.Set(component.Labels)) {
+			return true
+		}
+	}
+
+	return false
+}
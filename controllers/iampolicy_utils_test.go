@@ -12,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	iampolicyv1 "open-cluster-management.io/iam-policy-controller/api/v1"
@@ -58,3 +59,194 @@ func TestConvertPolicyStatusToString(t *testing.T) {
 
 	assert.NotNil(t, policyInString)
 }
+
+func TestGetSelectedNamespaces(t *testing.T) {
+	namespaces := []string{"default", "kube-system", "kube-public", "openshift-config", "app1", "app2"}
+
+	tests := []struct {
+		selector    iampolicyv1.Target
+		expected    []string
+		expectedErr bool
+	}{
+		{iampolicyv1.Target{}, []string{}, false},
+		{
+			iampolicyv1.Target{Include: []iampolicyv1.NonEmptyString{"*"}},
+			namespaces,
+			false,
+		},
+		{
+			iampolicyv1.Target{
+				Include: []iampolicyv1.NonEmptyString{"*"},
+				Exclude: []iampolicyv1.NonEmptyString{"kube-*", "openshift-*"},
+			},
+			[]string{"default", "app1", "app2"},
+			false,
+		},
+		{
+			iampolicyv1.Target{
+				Include: []iampolicyv1.NonEmptyString{"default", "app?"},
+				Exclude: []iampolicyv1.NonEmptyString{"app2"},
+			},
+			[]string{"default", "app1"},
+			false,
+		},
+		{iampolicyv1.Target{Include: []iampolicyv1.NonEmptyString{"[app"}}, nil, true},
+	}
+
+	for _, test := range tests {
+		selected, err := getSelectedNamespaces(namespaces, test.selector)
+
+		assert.Equal(t, test.expectedErr, err != nil)
+		assert.Equal(t, test.expected, selected)
+	}
+}
+
+func TestGetPrivilegedClusterRoles(t *testing.T) {
+	clusterRole := func(name string, rules ...rbacv1.PolicyRule) rbacv1.ClusterRole {
+		return rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: name}, Rules: rules}
+	}
+
+	clusterRoles := []rbacv1.ClusterRole{
+		clusterRole("cluster-admin", rbacv1.PolicyRule{
+			APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"},
+		}),
+		clusterRole(
+			"secret-reader",
+			rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get", "list"}},
+		),
+		clusterRole(
+			"secret-getter",
+			rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+		),
+		clusterRole(
+			"rbac-editor",
+			rbacv1.PolicyRule{
+				APIGroups: []string{"rbac.authorization.k8s.io"},
+				Resources: []string{"clusterroles"},
+				Verbs:     []string{"*"},
+			},
+			rbacv1.PolicyRule{
+				APIGroups: []string{"rbac.authorization.k8s.io"},
+				Resources: []string{"clusterrolebindings"},
+				Verbs:     []string{"create", "update"},
+			},
+		),
+		clusterRole("view", rbacv1.PolicyRule{
+			APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"},
+		}),
+		clusterRole("one-secret-admin", rbacv1.PolicyRule{
+			APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"one-secret"},
+			Verbs: []string{"*"},
+		}),
+	}
+
+	tests := map[string]struct {
+		rules    []iampolicyv1.PrivilegedRule
+		expected []string
+	}{
+		"all permissions": {
+			[]iampolicyv1.PrivilegedRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}},
+			[]string{"cluster-admin"},
+		},
+		"list secrets in the core API group by default": {
+			[]iampolicyv1.PrivilegedRule{{Resources: []string{"secrets"}, Verbs: []string{"list"}}},
+			[]string{"cluster-admin", "secret-reader"},
+		},
+		"every verb must be granted": {
+			[]iampolicyv1.PrivilegedRule{{Resources: []string{"secrets"}, Verbs: []string{"get", "list"}}},
+			[]string{"cluster-admin", "secret-reader"},
+		},
+		"permissions spread across rules": {
+			[]iampolicyv1.PrivilegedRule{{
+				APIGroups: []string{"rbac.authorization.k8s.io"},
+				Resources: []string{"clusterroles", "clusterrolebindings"},
+				Verbs:     []string{"update"},
+			}},
+			[]string{"cluster-admin", "rbac-editor"},
+		},
+		"any of the rules": {
+			[]iampolicyv1.PrivilegedRule{
+				{Resources: []string{"secrets"}, Verbs: []string{"get"}},
+				{Resources: []string{"pods"}, Verbs: []string{"list"}},
+			},
+			[]string{"cluster-admin", "secret-reader", "secret-getter", "view"},
+		},
+		"no match": {
+			[]iampolicyv1.PrivilegedRule{{Resources: []string{"nodes"}, Verbs: []string{"delete"}}},
+			[]string{"cluster-admin"},
+		},
+		"wildcard only matched by a wildcard": {
+			[]iampolicyv1.PrivilegedRule{{Resources: []string{"secrets"}, Verbs: []string{"*"}}},
+			[]string{"cluster-admin"},
+		},
+		"rules limited to resource names are skipped": {
+			[]iampolicyv1.PrivilegedRule{{Resources: []string{"secrets"}, Verbs: []string{"delete"}}},
+			[]string{"cluster-admin"},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, getPrivilegedClusterRoles(clusterRoles, test.rules))
+		})
+	}
+}
+
+func TestGetAggregatedClusterRoles(t *testing.T) {
+	clusterRole := func(name string, labels map[string]string, aggregated ...string) rbacv1.ClusterRole {
+		clusterRole := rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+
+		if len(aggregated) != 0 {
+			clusterRole.AggregationRule = &rbacv1.AggregationRule{}
+
+			for _, label := range aggregated {
+				clusterRole.AggregationRule.ClusterRoleSelectors = append(
+					clusterRole.AggregationRule.ClusterRoleSelectors,
+					metav1.LabelSelector{MatchLabels: map[string]string{label: "true"}},
+				)
+			}
+		}
+
+		return clusterRole
+	}
+
+	clusterRoles := []rbacv1.ClusterRole{
+		clusterRole("custom-admin", map[string]string{"aggregate-to-super-admin": "true"}, "aggregate-to-admin"),
+		clusterRole("custom-edit", map[string]string{"aggregate-to-admin": "true"}, "aggregate-to-edit"),
+		clusterRole("custom-edit-secrets", map[string]string{"aggregate-to-edit": "true"}),
+		clusterRole("custom-view", map[string]string{"aggregate-to-edit": "true", "aggregate-to-view": "true"}),
+		clusterRole("super-admin", nil, "aggregate-to-super-admin"),
+		clusterRole("viewer", nil, "aggregate-to-view"),
+		clusterRole("unrelated", map[string]string{"other": "true"}),
+	}
+
+	tests := map[string]struct {
+		name     string
+		expected []string
+	}{
+		"aggregated roles and the roles it is aggregated into": {
+			"custom-admin",
+			[]string{"custom-admin", "custom-edit", "custom-edit-secrets", "custom-view", "super-admin"},
+		},
+		"component role": {
+			"custom-edit",
+			[]string{"custom-edit", "custom-edit-secrets", "custom-view", "custom-admin", "super-admin"},
+		},
+		"directions are not mixed": {
+			"custom-edit-secrets",
+			[]string{"custom-edit-secrets", "custom-edit", "custom-admin", "super-admin"},
+		},
+		"not aggregated": {"unrelated", []string{"unrelated"}},
+		"missing role":   {"missing", []string{"missing"}},
+	}
+
+	for name, test := range tests {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, getAggregatedClusterRoles(clusterRoles, test.name))
+		})
+	}
+}
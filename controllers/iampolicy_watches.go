@@ -0,0 +1,327 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	v1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	rbaclisters "k8s.io/client-go/listers/rbac/v1"
+	"k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	iampolicyv1 "open-cluster-management.io/iam-policy-controller/api/v1"
+	"open-cluster-management.io/iam-policy-controller/pkg/groups"
+)
+
+// targetListers lists the objects cached by the informers on the target cluster
+type targetListers struct {
+	clusterRoleBindings rbaclisters.ClusterRoleBindingLister
+	clusterRoles        rbaclisters.ClusterRoleLister
+}
+
+// requestEvaluation requests the evaluation of the policy with the key in the next loop of
+// PeriodicallyExecIamPolicies, and wakes the loop up.
+func (e *Evaluator) requestEvaluation(key string) {
+	e.initialize()
+
+	e.evaluationRequestsLock.Lock()
+	e.evaluationRequests[key] = true
+	e.evaluationRequestsLock.Unlock()
+
+	select {
+	case e.evaluateNow <- struct{}{}:
+	default:
+	}
+}
+
+// takeEvaluationRequests returns the keys of the policies whose evaluation was requested, and clears them.
+func (e *Evaluator) takeEvaluationRequests() map[string]bool {
+	e.initialize()
+
+	e.evaluationRequestsLock.Lock()
+	defer e.evaluationRequestsLock.Unlock()
+
+	requests := e.evaluationRequests
+	e.evaluationRequests = map[string]bool{}
+
+	return requests
+}
+
+// watchTargetCluster adds watches on the ClusterRoleBindings, the ClusterRoles, and the OpenShift groups of
+// the target cluster to the controller, so that the policies affected by a change are reconciled and
+// evaluated right away. The informers are started with the manager, and their caches are used to list the
+// ClusterRoleBindings and the ClusterRoles once they are synced. The OpenShift groups are only watched when
+// they resolve the users of Group subjects and the cluster serves them.
+func (e *Evaluator) watchTargetCluster(mgr ctrl.Manager, blder *builder.Builder) error {
+	if e.TargetClient == nil {
+		return nil
+	}
+
+	factory := informers.NewSharedInformerFactory(e.TargetClient, 0)
+	clusterRoleBindingInformer := factory.Rbac().V1().ClusterRoleBindings()
+	clusterRoleInformer := factory.Rbac().V1().ClusterRoles()
+	listers := &targetListers{
+		clusterRoleBindings: clusterRoleBindingInformer.Lister(),
+		clusterRoles:        clusterRoleInformer.Lister(),
+	}
+
+	blder.WatchesRawSource(
+		&source.Informer{Informer: clusterRoleBindingInformer.Informer()},
+		handler.EnqueueRequestsFromMapFunc(func(_ context.Context, obj client.Object) []reconcile.Request {
+			binding, ok := obj.(*v1.ClusterRoleBinding)
+			if !ok {
+				return nil
+			}
+
+			return getPolicyRequests(e.getPoliciesForClusterRoleBindings(listers, []*v1.ClusterRoleBinding{binding}))
+		}),
+	).WatchesRawSource(
+		&source.Informer{Informer: clusterRoleInformer.Informer()},
+		handler.EnqueueRequestsFromMapFunc(func(_ context.Context, obj client.Object) []reconcile.Request {
+			return getPolicyRequests(e.getPoliciesForClusterRole(listers, obj.GetName()))
+		}),
+	)
+
+	var groupInformer cache.SharedIndexInformer
+
+	watchGroups, err := e.shouldWatchOpenShiftGroups()
+	if err != nil {
+		return err
+	}
+
+	if watchGroups {
+		// The dynamic informer factory can't be shut down, so the informer is run directly
+		groupInformer = dynamicinformer.NewFilteredDynamicInformer(
+			e.TargetDynamicClient, groups.OpenShiftGroupGVR, metav1.NamespaceAll, 0, cache.Indexers{}, nil,
+		).Informer()
+
+		blder.WatchesRawSource(
+			&source.Informer{Informer: groupInformer},
+			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, obj client.Object) []reconcile.Request {
+				group, ok := obj.(*unstructured.Unstructured)
+				if !ok {
+					return nil
+				}
+
+				return getPolicyRequests(e.getPoliciesForGroup(listers, group.GetName()))
+			}),
+		)
+	}
+
+	return mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		factory.Start(ctx.Done())
+		defer factory.Shutdown()
+
+		if groupInformer != nil {
+			var wg sync.WaitGroup
+
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				groupInformer.Run(ctx.Done())
+			}()
+
+			// Like the shutdown of the factory, wait for the informer to stop once the context is canceled
+			defer wg.Wait()
+		}
+
+		for informerType, ok := range factory.WaitForCacheSync(ctx.Done()) {
+			if !ok {
+				return fmt.Errorf("failed to sync the %v informer of the target cluster", informerType)
+			}
+		}
+
+		// Wait for the groups as well, so that their changes are watched before the policies are evaluated
+		if groupInformer != nil && !cache.WaitForCacheSync(ctx.Done(), groupInformer.HasSynced) {
+			return fmt.Errorf("failed to sync the OpenShift groups informer of the target cluster")
+		}
+
+		e.syncedListers.Store(listers)
+		defer e.syncedListers.Store(nil)
+
+		log.Info("Synced the ClusterRoleBindings, ClusterRoles, and groups of the target cluster")
+
+		<-ctx.Done()
+
+		return nil
+	}))
+}
+
+// shouldWatchOpenShiftGroups returns whether the users of Group subjects are resolved from the OpenShift
+// groups, and the target cluster serves them.
+func (e *Evaluator) shouldWatchOpenShiftGroups() (bool, error) {
+	if _, ok := e.GroupResolver.(*groups.OpenShiftResolver); !ok && e.GroupResolver != nil {
+		return false, nil
+	}
+
+	resources, err := e.TargetClient.Discovery().ServerResourcesForGroupVersion(
+		groups.OpenShiftGroupGVR.GroupVersion().String(),
+	)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed to discover the OpenShift groups API: %w", err)
+	}
+
+	for _, resource := range resources.APIResources {
+		if resource.Name == groups.OpenShiftGroupGVR.Resource {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// getPolicyRequests returns the reconcile requests of the policies, which request their evaluation.
+func getPolicyRequests(policies []*iampolicyv1.IamPolicy) []reconcile.Request {
+	requests := make([]reconcile.Request, 0, len(policies))
+
+	for _, policy := range policies {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: policy.Namespace, Name: policy.Name},
+		})
+	}
+
+	return requests
+}
+
+// getAvailablePolicies returns the policies to evaluate that match the filter.
+func (e *Evaluator) getAvailablePolicies(filter func(policy *iampolicyv1.IamPolicy) bool) []*iampolicyv1.IamPolicy {
+	e.availablePolicies.Mx.RLock()
+	defer e.availablePolicies.Mx.RUnlock()
+
+	var policies []*iampolicyv1.IamPolicy
+
+	for _, policy := range e.availablePolicies.PolicyMap {
+		if filter(policy) {
+			policies = append(policies, policy)
+		}
+	}
+
+	return policies
+}
+
+// getPoliciesForClusterRoleBindings returns the policies evaluating a role granted by any of the
+// ClusterRoleBindings.
+func (e *Evaluator) getPoliciesForClusterRoleBindings(
+	listers *targetListers, bindings []*v1.ClusterRoleBinding,
+) []*iampolicyv1.IamPolicy {
+	if len(bindings) == 0 {
+		return nil
+	}
+
+	clusterRoles, err := listCachedClusterRoles(listers)
+	if err != nil {
+		log.Error(err, "Failed to list the cached ClusterRoles")
+
+		return nil
+	}
+
+	return e.getAvailablePolicies(func(policy *iampolicyv1.IamPolicy) bool {
+		for _, role := range getRoleLimits(policy, clusterRoles) {
+			for _, binding := range bindings {
+				if binding.RoleRef.Kind == "ClusterRole" &&
+					slices.Contains(role.clusterRoleRefs, binding.RoleRef.Name) {
+					return true
+				}
+			}
+		}
+
+		return false
+	})
+}
+
+// getPoliciesForClusterRole returns the policies evaluating a role granted by the ClusterRole, and the
+// policies with privileged rules since the ClusterRole may now match them.
+func (e *Evaluator) getPoliciesForClusterRole(listers *targetListers, name string) []*iampolicyv1.IamPolicy {
+	clusterRoles, err := listCachedClusterRoles(listers)
+	if err != nil {
+		log.Error(err, "Failed to list the cached ClusterRoles")
+
+		return nil
+	}
+
+	return e.getAvailablePolicies(func(policy *iampolicyv1.IamPolicy) bool {
+		if len(policy.Spec.PrivilegedRules) != 0 {
+			return true
+		}
+
+		for _, role := range getRoleLimits(policy, clusterRoles) {
+			if role.name == name || slices.Contains(role.clusterRoleRefs, name) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// getPoliciesForGroup returns the policies evaluating a role granted to the group by a ClusterRoleBinding,
+// and the policies selecting namespaces since a RoleBinding may grant a role to the group.
+func (e *Evaluator) getPoliciesForGroup(listers *targetListers, group string) []*iampolicyv1.IamPolicy {
+	bindings, err := listers.clusterRoleBindings.List(labels.Everything())
+	if err != nil {
+		log.Error(err, "Failed to list the cached ClusterRoleBindings")
+
+		return nil
+	}
+
+	groupBindings := []*v1.ClusterRoleBinding{}
+
+	for _, binding := range bindings {
+		if slices.ContainsFunc(binding.Subjects, func(subject v1.Subject) bool {
+			return subject.Kind == "Group" && subject.Name == group
+		}) {
+			groupBindings = append(groupBindings, binding)
+		}
+	}
+
+	policies := e.getPoliciesForClusterRoleBindings(listers, groupBindings)
+
+	for _, policy := range e.getAvailablePolicies(func(policy *iampolicyv1.IamPolicy) bool {
+		return len(policy.Spec.NamespaceSelector.Include) != 0
+	}) {
+		if !slices.Contains(policies, policy) {
+			policies = append(policies, policy)
+		}
+	}
+
+	return policies
+}
+
+// listCachedClusterRoles returns copies of the ClusterRoles cached by the informer.
+func listCachedClusterRoles(listers *targetListers) ([]v1.ClusterRole, error) {
+	cached, err := listers.clusterRoles.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	clusterRoles := make([]v1.ClusterRole, 0, len(cached))
+
+	for _, clusterRole := range cached {
+		clusterRoles = append(clusterRoles, *clusterRole.DeepCopy())
+	}
+
+	return clusterRoles, nil
+}
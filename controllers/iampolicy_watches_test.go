@@ -0,0 +1,133 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	rbaclisters "k8s.io/client-go/listers/rbac/v1"
+	"k8s.io/client-go/tools/cache"
+
+	iampolicyv1 "open-cluster-management.io/iam-policy-controller/api/v1"
+)
+
+func newTestListers(t *testing.T, objects ...interface{}) *targetListers {
+	t.Helper()
+
+	clusterRoleBindings := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	clusterRoles := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+
+	for _, obj := range objects {
+		var err error
+
+		switch obj.(type) {
+		case *v1.ClusterRoleBinding:
+			err = clusterRoleBindings.Add(obj)
+		case *v1.ClusterRole:
+			err = clusterRoles.Add(obj)
+		}
+
+		assert.Nil(t, err)
+	}
+
+	return &targetListers{
+		clusterRoleBindings: rbaclisters.NewClusterRoleBindingLister(clusterRoleBindings),
+		clusterRoles:        rbaclisters.NewClusterRoleLister(clusterRoles),
+	}
+}
+
+func TestGetAffectedPolicies(t *testing.T) {
+	clusterAdmin := &iampolicyv1.IamPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin", Namespace: "managed"},
+		Spec:       iampolicyv1.IamPolicySpec{ClusterRole: "cluster-admin"},
+	}
+	admin := &iampolicyv1.IamPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin", Namespace: "managed"},
+		Spec: iampolicyv1.IamPolicySpec{
+			ClusterRoles:      []iampolicyv1.ClusterRoleLimit{{Name: "admin", MaxUsers: 1}},
+			NamespaceSelector: iampolicyv1.Target{Include: []iampolicyv1.NonEmptyString{"app-*"}},
+		},
+	}
+	privileged := &iampolicyv1.IamPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "privileged", Namespace: "managed"},
+		Spec: iampolicyv1.IamPolicySpec{
+			PrivilegedRules: []iampolicyv1.PrivilegedRule{{Resources: []string{"secrets"}, Verbs: []string{"*"}}},
+		},
+	}
+
+	evaluator := newTestEvaluator(nil, nil, clusterAdmin, admin, privileged)
+
+	adminsBinding := &v1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+		RoleRef:    v1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+		Subjects:   []v1.Subject{{Kind: "Group", Name: "admins"}},
+	}
+	listers := newTestListers(t, adminsBinding, &v1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "admin"}})
+
+	getNames := func(policies []*iampolicyv1.IamPolicy) []string {
+		names := []string{}
+
+		for _, request := range getPolicyRequests(policies) {
+			names = append(names, request.Name)
+		}
+
+		return names
+	}
+
+	// Only the policies evaluating the role of the binding are affected by it
+	assert.ElementsMatch(
+		t, []string{"cluster-admin"},
+		getNames(evaluator.getPoliciesForClusterRoleBindings(listers, []*v1.ClusterRoleBinding{adminsBinding})),
+	)
+	assert.Empty(t, evaluator.getPoliciesForClusterRoleBindings(listers, []*v1.ClusterRoleBinding{{
+		ObjectMeta: metav1.ObjectMeta{Name: "view"},
+		RoleRef:    v1.RoleRef{Kind: "ClusterRole", Name: "view"},
+	}}))
+
+	// A ClusterRole may now match the privileged rules of a policy
+	assert.ElementsMatch(
+		t, []string{"admin", "privileged"}, getNames(evaluator.getPoliciesForClusterRole(listers, "admin")),
+	)
+	assert.ElementsMatch(
+		t, []string{"privileged"}, getNames(evaluator.getPoliciesForClusterRole(listers, "view")),
+	)
+
+	// A group may be bound by a ClusterRoleBinding or by a RoleBinding in a selected namespace
+	assert.ElementsMatch(
+		t, []string{"cluster-admin", "admin"}, getNames(evaluator.getPoliciesForGroup(listers, "admins")),
+	)
+	assert.ElementsMatch(t, []string{"admin"}, getNames(evaluator.getPoliciesForGroup(listers, "developers")))
+}
+
+func TestEvaluationRequests(t *testing.T) {
+	evaluator := newTestEvaluator(nil, nil)
+
+	evaluator.requestEvaluation("managed.foo")
+	evaluator.requestEvaluation("managed.bar")
+	evaluator.requestEvaluation("managed.foo")
+
+	assert.Equal(t, map[string]bool{"managed.foo": true, "managed.bar": true}, evaluator.takeEvaluationRequests())
+	assert.Empty(t, evaluator.takeEvaluationRequests())
+
+	// The loop is woken up
+	select {
+	case <-evaluator.evaluateNow:
+	default:
+		t.Error("Expected the evaluation loop to be woken up")
+	}
+}
+
+func TestEvaluationRequestsStructLiteral(t *testing.T) {
+	evaluator := &Evaluator{}
+
+	evaluator.requestEvaluation("managed.foo")
+	assert.Equal(t, map[string]bool{"managed.foo": true}, evaluator.takeEvaluationRequests())
+
+	evaluator.availablePolicies.AddObject("managed.foo", &iampolicyv1.IamPolicy{})
+	evaluator.recordEvaluations(map[string]bool{"managed.foo": true}, time.Now())
+	assert.Len(t, evaluator.lastEvaluations, 1)
+}
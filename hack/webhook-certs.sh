@@ -0,0 +1,42 @@
+#!/bin/bash
+# Copyright Contributors to the Open Cluster Management project
+
+# Creates a self-signed certificate for the webhook service of the controller in the namespace, stores it in
+# the secret mounted by the controller, and injects its CA bundle into the IamPolicy CRD conversion webhook and,
+# once it is deployed, the validating webhook configuration. An existing secret is reused, so this can run
+# before and after the controller is deployed. On OpenShift, the service CA operator does this instead.
+
+set -euo pipefail
+
+NAMESPACE="${1:-open-cluster-management-agent-addon}"
+SERVICE="iam-policy-controller-webhook"
+SECRET="iam-policy-controller-webhook-cert"
+WEBHOOK_CONFIG="iam-policy-controller-validating-webhook"
+
+if ! kubectl get secret "${SECRET}" -n "${NAMESPACE}" >/dev/null 2>&1; then
+  CERT_DIR="$(mktemp -d)"
+  trap 'rm -rf "${CERT_DIR}"' EXIT
+
+  openssl req -x509 -newkey rsa:2048 -nodes -days 365 -subj "/CN=${SERVICE}-ca" \
+    -keyout "${CERT_DIR}/ca.key" -out "${CERT_DIR}/ca.crt" 2>/dev/null
+  openssl req -newkey rsa:2048 -nodes -subj "/CN=${SERVICE}.${NAMESPACE}.svc" \
+    -keyout "${CERT_DIR}/tls.key" -out "${CERT_DIR}/tls.csr" 2>/dev/null
+  printf "subjectAltName=DNS:%s,DNS:%s.%s,DNS:%s.%s.svc\n" \
+    "${SERVICE}" "${SERVICE}" "${NAMESPACE}" "${SERVICE}" "${NAMESPACE}" > "${CERT_DIR}/san.ext"
+  openssl x509 -req -days 365 -in "${CERT_DIR}/tls.csr" -CA "${CERT_DIR}/ca.crt" -CAkey "${CERT_DIR}/ca.key" \
+    -CAcreateserial -extfile "${CERT_DIR}/san.ext" -out "${CERT_DIR}/tls.crt" 2>/dev/null
+
+  kubectl create secret generic "${SECRET}" -n "${NAMESPACE}" --type=kubernetes.io/tls \
+    --from-file=tls.crt="${CERT_DIR}/tls.crt" --from-file=tls.key="${CERT_DIR}/tls.key" \
+    --from-file=ca.crt="${CERT_DIR}/ca.crt"
+fi
+
+CA_BUNDLE="$(kubectl get secret "${SECRET}" -n "${NAMESPACE}" -o jsonpath='{.data.ca\.crt}')"
+
+kubectl patch crd iampolicies.policy.open-cluster-management.io --type=json \
+  -p "[{\"op\": \"add\", \"path\": \"/spec/conversion/webhook/clientConfig/caBundle\", \"value\": \"${CA_BUNDLE}\"}]"
+
+if kubectl get validatingwebhookconfiguration "${WEBHOOK_CONFIG}" >/dev/null 2>&1; then
+  kubectl patch validatingwebhookconfiguration "${WEBHOOK_CONFIG}" --type=json \
+    -p "[{\"op\": \"add\", \"path\": \"/webhooks/0/clientConfig/caBundle\", \"value\": \"${CA_BUNDLE}\"}]"
+fi
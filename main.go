@@ -16,6 +16,7 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/go-logr/zapr"
 	"github.com/spf13/pflag"
@@ -36,8 +37,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 
 	iampolicyv1 "open-cluster-management.io/iam-policy-controller/api/v1"
+	iampolicyv1beta1 "open-cluster-management.io/iam-policy-controller/api/v1beta1"
 	"open-cluster-management.io/iam-policy-controller/controllers"
 	common "open-cluster-management.io/iam-policy-controller/pkg/common"
+	"open-cluster-management.io/iam-policy-controller/pkg/groups"
 	"open-cluster-management.io/iam-policy-controller/version"
 )
 
@@ -51,6 +54,7 @@ func init() {
 	utilruntime.Must(policiesv1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 	utilruntime.Must(iampolicyv1.AddToScheme(scheme))
+	utilruntime.Must(iampolicyv1beta1.AddToScheme(scheme))
 }
 
 func printVersion() {
@@ -70,9 +74,11 @@ func main() {
 	// Add flags registered by imported packages (e.g. glog and controller-runtime)
 	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
 
-	var clusterName, eventOnParent, hubConfigPath, targetKubeConfig, metricsAddr, probeAddr string
+	var clusterName, eventOnParent, hubConfigPath, targetKubeConfig, metricsAddr, probeAddr, webhookCertDir string
+	var groupResolverBackend, groupResolverSource string
+	var groupResolverCacheTTL, unknownComplianceGracePeriod time.Duration
 	var frequency uint
-	var enableLease, enableLeaderElection bool
+	var enableLease, enableLeaderElection, enableWebhooks bool
 
 	pflag.UintVar(&frequency, "update-frequency", 10, "The status update frequency (in seconds) of a mutation policy")
 	pflag.StringVar(
@@ -102,6 +108,43 @@ func main() {
 			"Enabling this will ensure there is only one active controller manager.")
 	pflag.StringVar(&metricsAddr, "metrics-bind-address", ":8383", "The address the metric endpoint binds to.")
 	pflag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	pflag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"Serve the IamPolicy webhooks, such as the conversion webhook between the API versions, on port 9443.")
+	pflag.StringVar(
+		&webhookCertDir,
+		"webhook-cert-dir",
+		"",
+		"The directory containing the tls.crt and tls.key files of the webhook server. "+
+			"Defaults to the controller-runtime default directory.",
+	)
+	pflag.StringVar(
+		&groupResolverBackend,
+		"group-resolver",
+		groups.OpenShiftBackend,
+		"The backend resolving the users of Group subjects on the target cluster. Options are: "+
+			"openshift/configmap/secret/webhook/ldap",
+	)
+	pflag.StringVar(
+		&groupResolverSource,
+		"group-resolver-source",
+		"",
+		"The <namespace>/<name> of the ConfigMap or Secret mapping groups to users in its groups.yaml key for "+
+			"the configmap and secret group resolvers, the <namespace>/<name> of the Secret configuring the ldap "+
+			"group resolver, or the URL of the webhook group resolver.",
+	)
+	pflag.DurationVar(
+		&groupResolverCacheTTL,
+		"group-resolver-cache-ttl",
+		5*time.Minute,
+		"How long the members of the groups returned by the ldap group resolver are cached. Set to 0 to disable.",
+	)
+	pflag.DurationVar(
+		&unknownComplianceGracePeriod,
+		"unknown-compliance-grace-period",
+		0,
+		"How long a policy that can't be fully evaluated keeps its compliance state before it is reported as "+
+			"UnknownCompliancy. Defaults to reporting it right away.",
+	)
 
 	pflag.Parse()
 
@@ -133,6 +176,7 @@ func main() {
 		MetricsBindAddress:     metricsAddr,
 		Namespace:              namespace,
 		Port:                   9443,
+		CertDir:                webhookCertDir,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "iam-policy-controller.open-cluster-management.io",
@@ -173,16 +217,47 @@ func main() {
 	targetK8sClient = kubernetes.NewForConfigOrDie(targetK8sConfig)
 	targetK8sDynamicClient = dynamic.NewForConfigOrDie(targetK8sConfig)
 
-	controllers.Initialize(&targetK8sClient, &targetK8sDynamicClient, eventOnParent)
+	groupResolver, err := groups.NewResolver(
+		groupResolverBackend, groupResolverSource, groupResolverCacheTTL, targetK8sClient, targetK8sDynamicClient,
+	)
+	if err != nil {
+		setupLog.Error(err, "Failed to configure the group resolver", "backend", groupResolverBackend)
+		os.Exit(1)
+	}
+
+	evaluator := controllers.NewEvaluator(
+		mgr.GetClient(),
+		mgr.GetEventRecorderFor("iampolicy-controller"),
+		targetK8sClient,
+		targetK8sDynamicClient,
+		eventOnParent,
+	)
+	evaluator.GroupResolver = groupResolver
+	evaluator.UnknownComplianceGracePeriod = unknownComplianceGracePeriod
+	evaluator.Frequency = frequency
+
+	// The evaluator periodically checks the policies and does the needed work to make sure the desired state is
+	// achieved. It only runs on the leader replica.
+	if err = mgr.Add(evaluator); err != nil {
+		setupLog.Error(err, "unable to add the policy evaluation loop")
+		os.Exit(1)
+	}
 
 	if err = (&controllers.IamPolicyReconciler{
-		Client:   mgr.GetClient(),
-		Recorder: mgr.GetEventRecorderFor("iampolicy-controller"),
-		Scheme:   mgr.GetScheme(),
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Evaluator: evaluator,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "IamPolicy")
 		os.Exit(1)
 	}
+
+	if enableWebhooks {
+		if err = (&iampolicyv1.IamPolicy{}).SetupWebhookWithManager(mgr, targetK8sClient); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "IamPolicy")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("Registering Components.")
@@ -192,14 +267,17 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := mgr.AddHealthzCheck("evaluation-loop", evaluator.HealthCheck); err != nil {
+		setupLog.Error(err, "unable to set up the evaluation loop health check")
+		os.Exit(1)
+	}
+
 	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
 
-	// PeriodicallyExecIamPolicies is the go-routine that periodically checks the policies
-	// and does the needed work to make sure the desired state is achieved
-	go controllers.PeriodicallyExecIamPolicies(frequency)
+	ctx := ctrl.SetupSignalHandler()
 
 	if enableLease {
 		operatorNs, err := common.GetOperatorNamespace()
@@ -235,7 +313,7 @@ func main() {
 
 	setupLog.Info("starting manager")
 
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
@@ -0,0 +1,70 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package groups
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// cachedMembers is the result of a lookup of the members of a group
+type cachedMembers struct {
+	users   []string
+	err     error
+	expires time.Time
+}
+
+// CachingResolver caches the members of groups returned by another resolver for a TTL. The groups that are
+// not found or invalid are cached as well, but the failed queries of the backend are retried on the next
+// lookup.
+type CachingResolver struct {
+	Resolver Resolver
+	TTL      time.Duration
+
+	mutex sync.Mutex
+	cache map[string]cachedMembers
+	// Returns the current time, only overridden in tests
+	now func() time.Time
+}
+
+var _ Resolver = &CachingResolver{}
+
+// NewCachingResolver returns a resolver caching the members of groups returned by the resolver for the TTL.
+func NewCachingResolver(resolver Resolver, ttl time.Duration) *CachingResolver {
+	return &CachingResolver{Resolver: resolver, TTL: ttl, cache: map[string]cachedMembers{}, now: time.Now}
+}
+
+// GetMembers returns the cached members of the group, or the members returned by the wrapped resolver when
+// they are not cached or expired.
+func (r *CachingResolver) GetMembers(ctx context.Context, group string) ([]string, error) {
+	r.mutex.Lock()
+	cached, ok := r.cache[group]
+	r.mutex.Unlock()
+
+	if ok && r.now().Before(cached.expires) {
+		return cached.users, cached.err
+	}
+
+	users, err := r.Resolver.GetMembers(ctx, group)
+	if err != nil && !errors.Is(err, ErrGroupNotFound) && !errors.Is(err, ErrInvalidGroup) {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Remove the expired entries so that the groups no longer referenced don't accumulate
+	now := r.now()
+
+	for cachedGroup, entry := range r.cache {
+		if !now.Before(entry.expires) {
+			delete(r.cache, cachedGroup)
+		}
+	}
+
+	r.cache[group] = cachedMembers{users: users, err: err, expires: now.Add(r.TTL)}
+
+	return users, err
+}
@@ -0,0 +1,79 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package groups
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingResolver returns the members of a static mapping and counts the lookups of each group
+type countingResolver struct {
+	members map[string][]string
+	lookups map[string]int
+	err     error
+}
+
+func (r *countingResolver) GetMembers(_ context.Context, group string) ([]string, error) {
+	r.lookups[group]++
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	users, ok := r.members[group]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrGroupNotFound, group)
+	}
+
+	return users, nil
+}
+
+func TestCachingResolver(t *testing.T) {
+	t.Parallel()
+
+	backend := &countingResolver{
+		members: map[string][]string{"admins": {"alice"}},
+		lookups: map[string]int{},
+	}
+	now := time.Now()
+
+	resolver := NewCachingResolver(backend, time.Minute)
+	resolver.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		users, err := resolver.GetMembers(context.TODO(), "admins")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"alice"}, users)
+
+		_, err = resolver.GetMembers(context.TODO(), "missing")
+		assert.ErrorIs(t, err, ErrGroupNotFound)
+	}
+
+	assert.Equal(t, map[string]int{"admins": 1, "missing": 1}, backend.lookups)
+
+	// The expired entries are looked up again
+	backend.members["admins"] = []string{"alice", "bob"}
+	now = now.Add(time.Minute)
+
+	users, err := resolver.GetMembers(context.TODO(), "admins")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, users)
+	assert.Equal(t, 2, backend.lookups["admins"])
+	assert.NotContains(t, resolver.cache, "missing")
+
+	// The failed queries of the backend are not cached
+	backend.err = errors.New("the server is unavailable")
+
+	for i := 0; i < 2; i++ {
+		_, err = resolver.GetMembers(context.TODO(), "other")
+		assert.Error(t, err)
+	}
+
+	assert.Equal(t, 2, backend.lookups["other"])
+}
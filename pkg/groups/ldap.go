@@ -0,0 +1,302 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package groups
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// The keys of the Secret configuring the LDAP resolver
+const (
+	// The ldap:// or ldaps:// URL of the LDAP server, required
+	LDAPURLKey = "url"
+	// The DN to bind as, the bind is anonymous when it is not set
+	LDAPBindDNKey = "bindDN"
+	// The password of the bind DN
+	LDAPBindPasswordKey = "bindPassword"
+	// The DN under which the groups are searched, required
+	LDAPBaseDNKey = "baseDN"
+	// The filter finding a group, with %s replaced by the escaped group name, defaults to (cn=%s)
+	LDAPGroupFilterKey = "groupFilter"
+	// The attribute of groups listing their members, defaults to member. The members are DNs, or usernames like
+	// the memberUid values of posixGroup entries.
+	LDAPMemberAttributeKey = "memberAttribute"
+	// The attribute of users with the username, defaults to uid
+	LDAPUserAttributeKey = "userAttribute"
+	// Whether to upgrade an ldap:// connection with StartTLS, set to true to enable
+	LDAPStartTLSKey = "startTLS"
+	// The PEM encoded CA certificates verifying the LDAP server, defaults to the system certificates
+	LDAPCAKey = "ca.crt"
+)
+
+const (
+	defaultLDAPGroupFilter     = "(cn=%s)"
+	defaultLDAPMemberAttribute = "member"
+	defaultLDAPUserAttribute   = "uid"
+	// The maximum depth of nested groups, which protects against very deep or misconfigured hierarchies
+	maxLDAPNestingDepth = 10
+)
+
+// ldapConn is the subset of the LDAP connection used by the resolver
+type ldapConn interface {
+	Bind(username, password string) error
+	Search(request *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close() error
+}
+
+// ldapConfig is the configuration of the LDAP resolver read from its Secret
+type ldapConfig struct {
+	url             string
+	bindDN          string
+	bindPassword    string
+	baseDN          string
+	groupFilter     string
+	memberAttribute string
+	userAttribute   string
+	startTLS        bool
+	tlsConfig       *tls.Config
+}
+
+// LDAPResolver resolves the members of groups from an LDAP server, including the members of nested groups.
+// It is configured by the keys of a Secret, which is read for each lookup so that rotated bind credentials
+// are used without a restart. Wrap it in a CachingResolver to avoid querying the server on each evaluation.
+type LDAPResolver struct {
+	Client    kubernetes.Interface
+	Namespace string
+	Name      string
+	// Connects to the LDAP server, only overridden in tests
+	dial func(config *ldapConfig) (ldapConn, error)
+}
+
+var _ Resolver = &LDAPResolver{}
+
+// GetMembers returns the users of the LDAP group, along with the users of the groups nested in it.
+func (r *LDAPResolver) GetMembers(ctx context.Context, group string) ([]string, error) {
+	config, err := r.getConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dial := r.dial
+	if dial == nil {
+		dial = dialLDAP
+	}
+
+	conn, err := dial(config)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	if config.bindDN != "" {
+		if err := conn.Bind(config.bindDN, config.bindPassword); err != nil {
+			return nil, fmt.Errorf("failed to bind to the LDAP server as %s: %w", config.bindDN, err)
+		}
+	}
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		config.baseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		fmt.Sprintf(config.groupFilter, ldap.EscapeFilter(group)), []string{config.memberAttribute}, nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search the LDAP group %s: %w", group, err)
+	}
+
+	switch len(result.Entries) {
+	case 0:
+		return nil, fmt.Errorf("%w: the LDAP group %s doesn't exist under %s", ErrGroupNotFound, group,
+			config.baseDN)
+	case 1:
+	default:
+		return nil, fmt.Errorf("%w: the LDAP group %s matches %d entries", ErrInvalidGroup, group,
+			len(result.Entries))
+	}
+
+	return expandLDAPMembers(conn, config, result.Entries[0])
+}
+
+// expandLDAPMembers returns the sorted usernames of the members of the group entry. A member entry with the
+// user attribute is a user, and a member entry with the member attribute is a nested group whose members
+// are expanded as well. A member that is not a DN, such as a memberUid value, is the username of a user found
+// under the base DN. The members that no longer exist are skipped.
+func expandLDAPMembers(conn ldapConn, config *ldapConfig, group *ldap.Entry) ([]string, error) {
+	visited := map[string]bool{group.DN: true}
+	users := map[string]bool{}
+	memberDNs := group.GetAttributeValues(config.memberAttribute)
+
+	for depth := 0; len(memberDNs) != 0; depth++ {
+		if depth == maxLDAPNestingDepth {
+			return nil, fmt.Errorf("%w: the LDAP group %s has groups nested more than %d levels deep",
+				ErrInvalidGroup, group.DN, maxLDAPNestingDepth)
+		}
+
+		var nestedMemberDNs []string
+
+		for _, memberDN := range memberDNs {
+			if visited[memberDN] {
+				continue
+			}
+
+			visited[memberDN] = true
+
+			if !isLDAPDN(memberDN) {
+				username, err := searchLDAPUser(conn, config, memberDN)
+				if err != nil {
+					return nil, err
+				}
+
+				if username != "" {
+					users[username] = true
+				}
+
+				continue
+			}
+
+			result, err := conn.Search(ldap.NewSearchRequest(
+				memberDN, ldap.ScopeBaseObject, ldap.NeverDerefAliases, 0, 0, false,
+				"(objectClass=*)", []string{config.userAttribute, config.memberAttribute}, nil,
+			))
+			if err != nil {
+				if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+					continue
+				}
+
+				return nil, fmt.Errorf("failed to get the LDAP member %s: %w", memberDN, err)
+			}
+
+			for _, entry := range result.Entries {
+				if username := entry.GetAttributeValue(config.userAttribute); username != "" {
+					users[username] = true
+
+					continue
+				}
+
+				nestedMemberDNs = append(nestedMemberDNs, entry.GetAttributeValues(config.memberAttribute)...)
+			}
+		}
+
+		memberDNs = nestedMemberDNs
+	}
+
+	usernames := make([]string, 0, len(users))
+
+	for username := range users {
+		usernames = append(usernames, username)
+	}
+
+	sort.Strings(usernames)
+
+	return usernames, nil
+}
+
+// isLDAPDN returns whether the member value is a DN rather than a username.
+func isLDAPDN(member string) bool {
+	dn, err := ldap.ParseDN(member)
+
+	return err == nil && len(dn.RDNs) != 0
+}
+
+// searchLDAPUser returns the username of the user with the username under the base DN, or an empty string when
+// the user doesn't exist.
+func searchLDAPUser(conn ldapConn, config *ldapConfig, username string) (string, error) {
+	result, err := conn.Search(ldap.NewSearchRequest(
+		config.baseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		fmt.Sprintf("(%s=%s)", config.userAttribute, ldap.EscapeFilter(username)), []string{config.userAttribute},
+		nil,
+	))
+	if err != nil {
+		return "", fmt.Errorf("failed to search the LDAP user %s: %w", username, err)
+	}
+
+	for _, entry := range result.Entries {
+		if value := entry.GetAttributeValue(config.userAttribute); value != "" {
+			return value, nil
+		}
+	}
+
+	return "", nil
+}
+
+// getConfig reads the configuration of the resolver from its Secret.
+func (r *LDAPResolver) getConfig(ctx context.Context) (*ldapConfig, error) {
+	secret, err := r.Client.CoreV1().Secrets(r.Namespace).Get(ctx, r.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the LDAP resolver Secret %s/%s: %w", r.Namespace, r.Name, err)
+	}
+
+	getValue := func(key string, defaultValue string) string {
+		if value := string(secret.Data[key]); value != "" {
+			return value
+		}
+
+		return defaultValue
+	}
+
+	config := &ldapConfig{
+		url:             getValue(LDAPURLKey, ""),
+		bindDN:          getValue(LDAPBindDNKey, ""),
+		bindPassword:    getValue(LDAPBindPasswordKey, ""),
+		baseDN:          getValue(LDAPBaseDNKey, ""),
+		groupFilter:     getValue(LDAPGroupFilterKey, defaultLDAPGroupFilter),
+		memberAttribute: getValue(LDAPMemberAttributeKey, defaultLDAPMemberAttribute),
+		userAttribute:   getValue(LDAPUserAttributeKey, defaultLDAPUserAttribute),
+		startTLS:        getValue(LDAPStartTLSKey, "false") == "true",
+	}
+
+	if config.url == "" || config.baseDN == "" {
+		return nil, fmt.Errorf("the LDAP resolver Secret %s/%s must set the %s and %s keys", r.Namespace, r.Name,
+			LDAPURLKey, LDAPBaseDNKey)
+	}
+
+	if strings.Count(config.groupFilter, "%s") != 1 {
+		return nil, fmt.Errorf("the %s key of the Secret %s/%s must contain %%s once", LDAPGroupFilterKey,
+			r.Namespace, r.Name)
+	}
+
+	serverURL, err := url.Parse(config.url)
+	if err != nil {
+		return nil, fmt.Errorf("the LDAP URL in the Secret %s/%s is invalid: %w", r.Namespace, r.Name, err)
+	}
+
+	config.tlsConfig = &tls.Config{ServerName: serverURL.Hostname(), MinVersion: tls.VersionTLS12}
+
+	if ca := secret.Data[LDAPCAKey]; len(ca) != 0 {
+		config.tlsConfig.RootCAs = x509.NewCertPool()
+
+		if !config.tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("the %s key of the Secret %s/%s has no valid PEM certificate", LDAPCAKey,
+				r.Namespace, r.Name)
+		}
+	}
+
+	return config, nil
+}
+
+// dialLDAP connects to the LDAP server, using TLS for ldaps:// URLs and when StartTLS is enabled.
+func dialLDAP(config *ldapConfig) (ldapConn, error) {
+	conn, err := ldap.DialURL(config.url, ldap.DialWithTLSConfig(config.tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the LDAP server %s: %w", config.url, err)
+	}
+
+	if config.startTLS {
+		if err := conn.StartTLS(config.tlsConfig); err != nil {
+			conn.Close()
+
+			return nil, fmt.Errorf("failed to start TLS with the LDAP server %s: %w", config.url, err)
+		}
+	}
+
+	return conn, nil
+}
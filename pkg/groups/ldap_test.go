@@ -0,0 +1,298 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package groups
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeLDAPConn is an in-memory LDAP directory of entries keyed by DN
+type fakeLDAPConn struct {
+	entries  map[string]map[string][]string
+	bindDN   string
+	password string
+	searches int
+}
+
+func (c *fakeLDAPConn) Bind(username, password string) error {
+	if username != c.bindDN || password != c.password {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+	}
+
+	return nil
+}
+
+func (c *fakeLDAPConn) Search(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	c.searches++
+
+	result := &ldap.SearchResult{}
+
+	if request.Scope == ldap.ScopeBaseObject {
+		attributes, ok := c.entries[request.BaseDN]
+		if !ok {
+			return nil, ldap.NewError(ldap.LDAPResultNoSuchObject, fmt.Errorf("%s doesn't exist", request.BaseDN))
+		}
+
+		result.Entries = append(result.Entries, ldap.NewEntry(request.BaseDN, attributes))
+
+		return result, nil
+	}
+
+	// Only the (<attribute>=<value>) filters are supported for subtree searches
+	attribute, value, _ := strings.Cut(strings.TrimSuffix(strings.TrimPrefix(request.Filter, "("), ")"), "=")
+
+	for dn, attributes := range c.entries {
+		if !strings.HasSuffix(dn, request.BaseDN) {
+			continue
+		}
+
+		for _, attributeValue := range attributes[attribute] {
+			if attributeValue == value {
+				result.Entries = append(result.Entries, ldap.NewEntry(dn, attributes))
+
+				break
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (c *fakeLDAPConn) Close() error {
+	return nil
+}
+
+func TestLDAPResolver(t *testing.T) {
+	t.Parallel()
+
+	user := func(uid string) map[string][]string {
+		return map[string][]string{"cn": {uid}, "uid": {uid}}
+	}
+
+	group := func(cn string, members ...string) map[string][]string {
+		return map[string][]string{"cn": {cn}, "member": members}
+	}
+
+	conn := &fakeLDAPConn{
+		bindDN:   "cn=reader,dc=example,dc=com",
+		password: "secret",
+		entries: map[string]map[string][]string{
+			"uid=alice,ou=people,dc=example,dc=com": user("alice"),
+			"uid=bob,ou=people,dc=example,dc=com":   user("bob"),
+			"uid=carol,ou=people,dc=example,dc=com": user("carol"),
+			"cn=admins,ou=groups,dc=example,dc=com": group(
+				"admins",
+				"uid=alice,ou=people,dc=example,dc=com",
+				"cn=sre,ou=groups,dc=example,dc=com",
+				"uid=deleted,ou=people,dc=example,dc=com",
+			),
+			// The nested groups reference each other
+			"cn=sre,ou=groups,dc=example,dc=com": group(
+				"sre",
+				"uid=bob,ou=people,dc=example,dc=com",
+				"uid=alice,ou=people,dc=example,dc=com",
+				"cn=admins,ou=groups,dc=example,dc=com",
+				"cn=on-call,ou=groups,dc=example,dc=com",
+			),
+			"cn=on-call,ou=groups,dc=example,dc=com":   group("on-call", "uid=carol,ou=people,dc=example,dc=com"),
+			"cn=duplicate,ou=groups,dc=example,dc=com": group("duplicate"),
+			"cn=duplicate,ou=teams,dc=example,dc=com":  group("duplicate"),
+		},
+	}
+
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ldap", Namespace: "iam"},
+		Data: map[string][]byte{
+			LDAPURLKey:          []byte("ldaps://ldap.example.com"),
+			LDAPBindDNKey:       []byte("cn=reader,dc=example,dc=com"),
+			LDAPBindPasswordKey: []byte("secret"),
+			LDAPBaseDNKey:       []byte("dc=example,dc=com"),
+		},
+	})
+
+	var dialedConfig *ldapConfig
+
+	resolver := &LDAPResolver{
+		Client:    client,
+		Namespace: "iam",
+		Name:      "ldap",
+		dial: func(config *ldapConfig) (ldapConn, error) {
+			dialedConfig = config
+
+			return conn, nil
+		},
+	}
+
+	users, err := resolver.GetMembers(context.TODO(), "admins")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob", "carol"}, users)
+	assert.Equal(t, "ldap.example.com", dialedConfig.tlsConfig.ServerName)
+	assert.False(t, dialedConfig.startTLS)
+
+	users, err = resolver.GetMembers(context.TODO(), "on-call")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"carol"}, users)
+
+	_, err = resolver.GetMembers(context.TODO(), "missing")
+	assert.ErrorIs(t, err, ErrGroupNotFound)
+
+	_, err = resolver.GetMembers(context.TODO(), "duplicate")
+	assert.ErrorIs(t, err, ErrInvalidGroup)
+
+	// The credentials are read from the Secret on each lookup
+	conn.password = "rotated"
+
+	_, err = resolver.GetMembers(context.TODO(), "admins")
+	assert.ErrorContains(t, err, "failed to bind to the LDAP server")
+}
+
+func TestLDAPResolverMemberUid(t *testing.T) {
+	t.Parallel()
+
+	conn := &fakeLDAPConn{
+		entries: map[string]map[string][]string{
+			"uid=alice,ou=people,dc=example,dc=com": {"cn": {"Alice"}, "uid": {"alice"}},
+			"uid=bob,ou=people,dc=example,dc=com":   {"cn": {"Bob"}, "uid": {"bob"}},
+			// A posixGroup lists the uid of its members rather than their DN
+			"cn=developers,ou=groups,dc=example,dc=com": {
+				"cn":        {"developers"},
+				"memberUid": {"alice", "bob", "deleted"},
+			},
+		},
+	}
+
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ldap", Namespace: "iam"},
+		Data: map[string][]byte{
+			LDAPURLKey:             []byte("ldaps://ldap.example.com"),
+			LDAPBaseDNKey:          []byte("dc=example,dc=com"),
+			LDAPMemberAttributeKey: []byte("memberUid"),
+		},
+	})
+
+	resolver := &LDAPResolver{
+		Client:    client,
+		Namespace: "iam",
+		Name:      "ldap",
+		dial: func(_ *ldapConfig) (ldapConn, error) {
+			return conn, nil
+		},
+	}
+
+	users, err := resolver.GetMembers(context.TODO(), "developers")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, users)
+}
+
+func TestLDAPResolverNestingDepth(t *testing.T) {
+	t.Parallel()
+
+	conn := &fakeLDAPConn{entries: map[string]map[string][]string{}}
+
+	for i := 0; i <= maxLDAPNestingDepth; i++ {
+		dn := fmt.Sprintf("cn=level%d,dc=example,dc=com", i)
+		conn.entries[dn] = map[string][]string{
+			"cn":     {fmt.Sprintf("level%d", i)},
+			"member": {fmt.Sprintf("cn=level%d,dc=example,dc=com", i+1)},
+		}
+	}
+
+	config := &ldapConfig{memberAttribute: "member", userAttribute: "uid"}
+	group := ldap.NewEntry("cn=level0,dc=example,dc=com", conn.entries["cn=level0,dc=example,dc=com"])
+
+	_, err := expandLDAPMembers(conn, config, group)
+	assert.ErrorIs(t, err, ErrInvalidGroup)
+}
+
+func TestLDAPResolverConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		data     map[string]string
+		expected *ldapConfig
+	}{
+		"defaults": {
+			data: map[string]string{LDAPURLKey: "ldap://ldap.example.com:389", LDAPBaseDNKey: "dc=example,dc=com"},
+			expected: &ldapConfig{
+				url:             "ldap://ldap.example.com:389",
+				baseDN:          "dc=example,dc=com",
+				groupFilter:     "(cn=%s)",
+				memberAttribute: "member",
+				userAttribute:   "uid",
+			},
+		},
+		"custom": {
+			data: map[string]string{
+				LDAPURLKey:             "ldap://ldap.example.com",
+				LDAPBaseDNKey:          "dc=example,dc=com",
+				LDAPGroupFilterKey:     "(&(objectClass=group)(sAMAccountName=%s))",
+				LDAPMemberAttributeKey: "uniqueMember",
+				LDAPUserAttributeKey:   "sAMAccountName",
+				LDAPStartTLSKey:        "true",
+			},
+			expected: &ldapConfig{
+				url:             "ldap://ldap.example.com",
+				baseDN:          "dc=example,dc=com",
+				groupFilter:     "(&(objectClass=group)(sAMAccountName=%s))",
+				memberAttribute: "uniqueMember",
+				userAttribute:   "sAMAccountName",
+				startTLS:        true,
+			},
+		},
+		"no base DN": {
+			data: map[string]string{LDAPURLKey: "ldap://ldap.example.com"},
+		},
+		"filter without the group": {
+			data: map[string]string{
+				LDAPURLKey: "ldap://ldap.example.com", LDAPBaseDNKey: "dc=example,dc=com", LDAPGroupFilterKey: "(cn=*)",
+			},
+		},
+		"invalid CA": {
+			data: map[string]string{
+				LDAPURLKey: "ldaps://ldap.example.com", LDAPBaseDNKey: "dc=example,dc=com", LDAPCAKey: "not a CA",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "ldap", Namespace: "iam"},
+				Data:       map[string][]byte{},
+			}
+
+			for key, value := range test.data {
+				secret.Data[key] = []byte(value)
+			}
+
+			resolver := &LDAPResolver{Client: fake.NewSimpleClientset(secret), Namespace: "iam", Name: "ldap"}
+
+			config, err := resolver.getConfig(context.TODO())
+			if test.expected == nil {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "ldap.example.com", config.tlsConfig.ServerName)
+
+			config.tlsConfig = nil
+			assert.Equal(t, test.expected, config)
+		})
+	}
+}
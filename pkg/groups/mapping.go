@@ -0,0 +1,77 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package groups
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// MappingKey is the key of the ConfigMap or Secret data that maps each group name to the list of its users
+const MappingKey = "groups.yaml"
+
+// MappingResolver resolves the members of groups from a static mapping in a ConfigMap or a Secret. The
+// MappingKey of the data is a YAML object mapping each group name to the list of its users, for example:
+//
+//	admins:
+//	- alice
+//	- bob
+type MappingResolver struct {
+	Client    kubernetes.Interface
+	Namespace string
+	Name      string
+	// Whether the mapping is in a Secret rather than a ConfigMap
+	Secret bool
+}
+
+var _ Resolver = &MappingResolver{}
+
+// GetMembers returns the users of the group in the mapping. The ConfigMap or Secret is read each time so
+// that the changes to the mapping apply to the next evaluation.
+func (r *MappingResolver) GetMembers(ctx context.Context, group string) ([]string, error) {
+	var data []byte
+	var kind string
+
+	if r.Secret {
+		kind = "Secret"
+
+		secret, err := r.Client.CoreV1().Secrets(r.Namespace).Get(ctx, r.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the %s %s/%s: %w", kind, r.Namespace, r.Name, err)
+		}
+
+		data = secret.Data[MappingKey]
+	} else {
+		kind = "ConfigMap"
+
+		configMap, err := r.Client.CoreV1().ConfigMaps(r.Namespace).Get(ctx, r.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the %s %s/%s: %w", kind, r.Namespace, r.Name, err)
+		}
+
+		data = []byte(configMap.Data[MappingKey])
+	}
+
+	mapping := map[string][]string{}
+
+	if err := yaml.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("%w: the %s key of the %s %s/%s is not a mapping of groups to users: %w",
+			ErrInvalidGroup, MappingKey, kind, r.Namespace, r.Name, err)
+	}
+
+	users, ok := mapping[group]
+	if !ok {
+		return nil, fmt.Errorf("%w: the group %s is not in the %s %s/%s", ErrGroupNotFound, group, kind,
+			r.Namespace, r.Name)
+	}
+
+	if users == nil {
+		return []string{}, nil
+	}
+
+	return users, nil
+}
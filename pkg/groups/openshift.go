@@ -0,0 +1,54 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package groups
+
+import (
+	"context"
+	"fmt"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// OpenShiftGroupGVR is the resource of the OpenShift groups. ClusterRoleBinding objects in OpenShift set
+// the API group of a Group subject to rbac.authorization.k8s.io even though it should be user.openshift.io.
+var OpenShiftGroupGVR = schema.GroupVersionResource{
+	Group:    "user.openshift.io",
+	Version:  "v1",
+	Resource: "groups",
+}
+
+// OpenShiftResolver resolves the members of the OpenShift user.openshift.io/v1 groups.
+type OpenShiftResolver struct {
+	Client dynamic.Interface
+}
+
+var _ Resolver = &OpenShiftResolver{}
+
+// GetMembers returns the users of the OpenShift group with the same name.
+func (r *OpenShiftResolver) GetMembers(ctx context.Context, group string) ([]string, error) {
+	openShiftUserGroup, err := r.Client.Resource(OpenShiftGroupGVR).Get(ctx, group, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: the OpenShift group %s doesn't exist", ErrGroupNotFound, group)
+		}
+
+		return nil, fmt.Errorf("failed to get OpenShift group %s: %w", group, err)
+	}
+
+	// The users field is null or omitted when the group has no users
+	if openShiftUserGroup.Object["users"] == nil {
+		return []string{}, nil
+	}
+
+	users, _, err := unstructured.NestedStringSlice(openShiftUserGroup.Object, "users")
+	if err != nil {
+		return nil, fmt.Errorf("%w: the users of the OpenShift group %s are not a list of strings: %w",
+			ErrInvalidGroup, group, err)
+	}
+
+	return users, nil
+}
@@ -0,0 +1,91 @@
+// Copyright Contributors to the Open Cluster Management project
+
+// Package groups resolves the users that are members of the Group subjects of role bindings, since
+// Kubernetes doesn't store the members of groups itself.
+package groups
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// webhookTimeout is the maximum duration of a webhook request made by the resolver returned by NewResolver
+const webhookTimeout = 10 * time.Second
+
+var (
+	// ErrGroupNotFound is returned when the group doesn't exist in the backend of the resolver
+	ErrGroupNotFound = errors.New("the group was not found")
+	// ErrInvalidGroup is returned when the members of the group can't be read from the backend of the resolver
+	ErrInvalidGroup = errors.New("the group is invalid")
+)
+
+// Resolver returns the users that are members of a group.
+type Resolver interface {
+	// GetMembers returns the users of the group. ErrGroupNotFound or ErrInvalidGroup is wrapped in the
+	// returned error when the group doesn't exist or can't be read, and any other error means the backend
+	// could not be queried.
+	GetMembers(ctx context.Context, group string) ([]string, error)
+}
+
+// The names of the resolver backends accepted by NewResolver
+const (
+	OpenShiftBackend = "openshift"
+	ConfigMapBackend = "configmap"
+	SecretBackend    = "secret"
+	WebhookBackend   = "webhook"
+	LDAPBackend      = "ldap"
+)
+
+// NewResolver returns the resolver of the backend. The source is the <namespace>/<name> of the ConfigMap or
+// Secret with the mapping of groups to users for the configmap and secret backends, of the Secret with the
+// configuration of the ldap backend, the URL of the webhook for the webhook backend, and is unused for the
+// openshift backend. The members of the LDAP groups are cached for the cache TTL, unless it is zero.
+func NewResolver(
+	backend string,
+	source string,
+	cacheTTL time.Duration,
+	client kubernetes.Interface,
+	dynamicClient dynamic.Interface,
+) (Resolver, error) {
+	switch backend {
+	case OpenShiftBackend:
+		return &OpenShiftResolver{Client: dynamicClient}, nil
+	case ConfigMapBackend, SecretBackend, LDAPBackend:
+		namespace, name, found := strings.Cut(source, "/")
+		if !found || namespace == "" || name == "" {
+			return nil, fmt.Errorf("the %s group resolver source must be in the <namespace>/<name> format", backend)
+		}
+
+		if backend == LDAPBackend {
+			var resolver Resolver = &LDAPResolver{Client: client, Namespace: namespace, Name: name}
+
+			if cacheTTL > 0 {
+				resolver = NewCachingResolver(resolver, cacheTTL)
+			}
+
+			return resolver, nil
+		}
+
+		return &MappingResolver{
+			Client: client, Namespace: namespace, Name: name, Secret: backend == SecretBackend,
+		}, nil
+	case WebhookBackend:
+		webhookURL, err := url.Parse(source)
+		if err != nil || webhookURL.Host == "" || (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") {
+			return nil, errors.New("the webhook group resolver source must be an http or https URL")
+		}
+
+		return &WebhookResolver{URL: source, Client: &http.Client{Timeout: webhookTimeout}}, nil
+	default:
+		return nil, fmt.Errorf("the group resolver backend %s is not one of %s, %s, %s, %s, or %s", backend,
+			OpenShiftBackend, ConfigMapBackend, SecretBackend, WebhookBackend, LDAPBackend)
+	}
+}
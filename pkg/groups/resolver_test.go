@@ -0,0 +1,201 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package groups
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newOpenShiftGroup(name string, users interface{}) *unstructured.Unstructured {
+	group := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "user.openshift.io/v1",
+		"kind":       "Group",
+		"metadata":   map[string]interface{}{"name": name},
+	}}
+
+	if users != nil {
+		group.Object["users"] = users
+	}
+
+	return group
+}
+
+func TestOpenShiftResolver(t *testing.T) {
+	t.Parallel()
+
+	// The Group type has no omitempty on its users, so a group without users has null users
+	nullUsers := newOpenShiftGroup("null", nil)
+	nullUsers.Object["users"] = nil
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{OpenShiftGroupGVR: "GroupList"},
+		newOpenShiftGroup("admins", []interface{}{"alice", "bob"}),
+		newOpenShiftGroup("empty", nil),
+		nullUsers,
+		newOpenShiftGroup("invalid", "alice"),
+	)
+	resolver := &OpenShiftResolver{Client: client}
+
+	users, err := resolver.GetMembers(context.TODO(), "admins")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, users)
+
+	users, err = resolver.GetMembers(context.TODO(), "empty")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, users)
+
+	users, err = resolver.GetMembers(context.TODO(), "null")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, users)
+
+	_, err = resolver.GetMembers(context.TODO(), "invalid")
+	assert.ErrorIs(t, err, ErrInvalidGroup)
+
+	_, err = resolver.GetMembers(context.TODO(), "missing")
+	assert.ErrorIs(t, err, ErrGroupNotFound)
+}
+
+func TestMappingResolver(t *testing.T) {
+	t.Parallel()
+
+	mapping := "admins:\n- alice\n- bob\nempty: []\n"
+	client := fake.NewSimpleClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "groups", Namespace: "iam"},
+			Data:       map[string]string{MappingKey: mapping},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "groups", Namespace: "iam"},
+			Data:       map[string][]byte{MappingKey: []byte(mapping)},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid", Namespace: "iam"},
+			Data:       map[string]string{MappingKey: "admins: alice"},
+		},
+	)
+
+	for _, secret := range []bool{false, true} {
+		resolver := &MappingResolver{Client: client, Namespace: "iam", Name: "groups", Secret: secret}
+
+		users, err := resolver.GetMembers(context.TODO(), "admins")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"alice", "bob"}, users)
+
+		users, err = resolver.GetMembers(context.TODO(), "empty")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{}, users)
+
+		_, err = resolver.GetMembers(context.TODO(), "missing")
+		assert.ErrorIs(t, err, ErrGroupNotFound)
+	}
+
+	resolver := &MappingResolver{Client: client, Namespace: "iam", Name: "invalid"}
+
+	_, err := resolver.GetMembers(context.TODO(), "admins")
+	assert.ErrorIs(t, err, ErrInvalidGroup)
+
+	// A missing ConfigMap is an error of the backend rather than of the group
+	resolver = &MappingResolver{Client: client, Namespace: "iam", Name: "missing"}
+
+	_, err = resolver.GetMembers(context.TODO(), "admins")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrGroupNotFound))
+}
+
+func TestWebhookResolver(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("group") {
+		case "admins":
+			_, _ = w.Write([]byte(`{"users": ["alice", "bob"]}`))
+		case "empty":
+			_, _ = w.Write([]byte(`{}`))
+		case "invalid":
+			_, _ = w.Write([]byte(`users`))
+		case "unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	resolver := &WebhookResolver{URL: server.URL + "/groups?token=abc"}
+
+	users, err := resolver.GetMembers(context.TODO(), "admins")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, users)
+
+	users, err = resolver.GetMembers(context.TODO(), "empty")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, users)
+
+	_, err = resolver.GetMembers(context.TODO(), "invalid")
+	assert.ErrorIs(t, err, ErrInvalidGroup)
+
+	_, err = resolver.GetMembers(context.TODO(), "missing")
+	assert.ErrorIs(t, err, ErrGroupNotFound)
+
+	_, err = resolver.GetMembers(context.TODO(), "unavailable")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrGroupNotFound))
+}
+
+func TestNewResolver(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		backend  string
+		source   string
+		cacheTTL time.Duration
+		expected Resolver
+	}{
+		"openshift": {OpenShiftBackend, "", 0, &OpenShiftResolver{}},
+		"configmap": {ConfigMapBackend, "iam/groups", 0, &MappingResolver{Namespace: "iam", Name: "groups"}},
+		"secret":    {SecretBackend, "iam/groups", 0, &MappingResolver{Namespace: "iam", Name: "groups", Secret: true}},
+		"webhook":   {WebhookBackend, "https://groups.example.com/members", 0, &WebhookResolver{}},
+		"ldap":      {LDAPBackend, "iam/ldap", time.Minute, &CachingResolver{}},
+		"no cache":  {LDAPBackend, "iam/ldap", 0, &LDAPResolver{}},
+		"no name":   {ConfigMapBackend, "iam", 0, nil},
+		"no URL":    {WebhookBackend, "", 0, nil},
+		"unknown":   {"keycloak", "", 0, nil},
+	}
+
+	for name, test := range tests {
+		test := test
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			resolver, err := NewResolver(test.backend, test.source, test.cacheTTL, nil, nil)
+			if test.expected == nil {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.IsType(t, test.expected, resolver)
+
+			if mappingResolver, ok := resolver.(*MappingResolver); ok {
+				assert.Equal(t, test.expected, mappingResolver)
+			}
+		})
+	}
+}
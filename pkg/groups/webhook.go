@@ -0,0 +1,81 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package groups
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// WebhookResolver resolves the members of groups by sending a GET request to an HTTP endpoint with the
+// group name in the group query parameter. The endpoint responds with a JSON object with the users of the
+// group in the users field, for example {"users": ["alice", "bob"]}, or with the 404 status code when the
+// group doesn't exist.
+type WebhookResolver struct {
+	URL string
+	// The client sending the requests, which defaults to http.DefaultClient
+	Client *http.Client
+}
+
+var _ Resolver = &WebhookResolver{}
+
+// webhookResponse is the body of a successful webhook response
+type webhookResponse struct {
+	Users []string `json:"users"`
+}
+
+// GetMembers returns the users of the group returned by the webhook.
+func (r *WebhookResolver) GetMembers(ctx context.Context, group string) ([]string, error) {
+	requestURL, err := url.Parse(r.URL)
+	if err != nil {
+		return nil, fmt.Errorf("the group resolver URL is invalid: %w", err)
+	}
+
+	query := requestURL.Query()
+	query.Set("group", group)
+	requestURL.RawQuery = query.Encode()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request for the group %s: %w", group, err)
+	}
+
+	request.Header.Set("Accept", "application/json")
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the group resolver webhook for the group %s: %w", group, err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: the group resolver webhook doesn't know the group %s", ErrGroupNotFound, group)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the group resolver webhook responded with the status %s for the group %s",
+			response.Status, group)
+	}
+
+	body := webhookResponse{}
+
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%w: the group resolver webhook response for the group %s is invalid: %w",
+			ErrInvalidGroup, group, err)
+	}
+
+	if body.Users == nil {
+		return []string{}, nil
+	}
+
+	return body.Users, nil
+}
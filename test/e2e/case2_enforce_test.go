@@ -0,0 +1,60 @@
+// Copyright Contributors to the Open Cluster Management project
+
+package e2e
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Test enforcing the default clusterrole (cluster-admin)", Ordered, func() {
+	const (
+		policyName = "rule-of-two-enforced"
+		policyYAML = "../resources/case2_enforce/iam-policy-enforce.yaml"
+		crbName    = "sith-lords-are-admins"
+		crbYAML    = "../resources/admin-crb.yaml"
+	)
+
+	AfterAll(func() {
+		Kubectl("delete", "-f", policyYAML, "--ignore-not-found", "-n", testNamespace)
+		Kubectl("delete", "-f", crbYAML, "--ignore-not-found")
+		Kubectl("delete", "event", "--field-selector=involvedObject.name="+policyName, "-n", testNamespace)
+	})
+
+	It("Should remove the excess subjects from the cluster role binding", func() {
+		By("Creating a cluster role binding with one user above the limit")
+		Kubectl("apply", "-f", crbYAML)
+
+		By("Creating the enforced policy")
+		Kubectl("apply", "-f", policyYAML, "-n", testNamespace)
+
+		By("Verifying that the last subject is removed from the binding")
+		Eventually(func(g Gomega) []string {
+			crb, err := clientManaged.RbacV1().ClusterRoleBindings().Get(context.TODO(), crbName, metav1.GetOptions{})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			names := []string{}
+			for _, subject := range crb.Subjects {
+				names = append(names, subject.Name)
+			}
+
+			return names
+		}, defaultTimeoutSeconds, 1).Should(Equal([]string{"darth-sidious", "darth-maul"}))
+
+		By("Verifying that the policy is compliant and reports the removed subject")
+		Eventually(func() interface{} {
+			pol := GetWithTimeout(gvrIamPolicy, policyName, testNamespace, true)
+			comp, _, _ := unstructured.NestedString(pol.Object, "status", "compliant")
+
+			return comp
+		}, defaultTimeoutSeconds, 1).Should(Equal("Compliant"))
+
+		pol := GetWithTimeout(gvrIamPolicy, policyName, testNamespace, true)
+		removed, _, _ := unstructured.NestedSlice(pol.Object, "status", "removedSubjects")
+		Expect(removed).To(HaveLen(1))
+	})
+})